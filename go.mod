@@ -3,7 +3,6 @@ module a-star
 go 1.21.4
 
 require (
-	github.com/co0p/tankism v0.0.0-20230903205805-1ebc2e88a661
 	github.com/hajimehoshi/ebiten/v2 v2.6.3
 	github.com/lafriks/go-tiled v0.12.0
 )
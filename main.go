@@ -2,21 +2,375 @@ package main
 
 import (
 	"embed"
+	"flag"
 	"fmt"
+	"image"
+	"io/fs"
+	"math/rand"
+	"os"
+	"path"
 
+	"a-star/src/astar"
+	"a-star/src/capture"
+	"a-star/src/export"
 	"a-star/src/game"
+	"a-star/src/input"
+	"a-star/src/utils"
 
-	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/lafriks/go-tiled"
 )
 
 //go:embed assets/*
 var EmbeddedAssets embed.FS
 
+var (
+	compare   = flag.Int("compare", 0, "compare the search algorithms on `n` random origin/dest pairs and exit")
+	seed      = flag.Int64("seed", 1, "random seed used to pick the pairs for -compare and by -headless runs")
+	mapFile   = flag.String("map", "", "load the map from the tmx `file` instead of the built-in one")
+	assetsDir = flag.String("assets", "", "load sprites and behaviour trees from `dir` instead of the built-in assets")
+	headless  = flag.Int("headless", 0, "run `n` frames without a window, print where everyone is and exit")
+	script    = flag.String("script", "", "play the input in `file` during -headless runs")
+	caught    = flag.Bool("until-caught", false, "stop -headless runs once every chicken is on the player's cell, failing if they never are")
+	record    = flag.String("record", "", "record the input of the session to `file` when the game is closed, or fails")
+	replay    = flag.String("replay", "", "play the session recorded in `file`, checking the game state along the way")
+	verify    = flag.Bool("verify", false, "with -replay, check the recording without a window and exit")
+	load      = flag.String("load", "", "start from the game saved in `file`")
+	exportTo  = flag.String("export", "", "export a search and its trace to `file`, .json or .svg, and exit")
+	captureTo = flag.String("capture", "", "capture -headless runs, or else the search step by step, to `file`, .gif or numbered .png files, and exit")
+	from      = flag.String("from", "", "origin cell `x,y` of the -export or -capture search, random if not given")
+	to        = flag.String("to", "", "dest cell `x,y` of the -export or -capture search, random if not given")
+	search    = flag.String("search", "A*", "`algorithm` of the -export or -capture search")
+	heuristic = flag.String("heuristic", "Manhattan", "`heuristic` of the -export or -capture search")
+)
+
 func main() {
-	gameObj := game.NewGame(EmbeddedAssets)
+	flag.Parse()
+
+	assets, mapFS, mapDir, mapPath := openAssets()
+
+	if *compare > 0 {
+		if err := runComparison(mapFS, mapPath, *compare, *seed); err != nil {
+			fmt.Println("failed to compare algorithms:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *exportTo != "" {
+		if err := runExport(mapFS, mapPath); err != nil {
+			fmt.Println("failed to export search:", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if *captureTo != "" && *headless == 0 {
+		if err := runCapture(mapFS, mapPath); err != nil {
+			fmt.Println("failed to capture search:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	levels := []game.Level{{Name: path.Base(mapPath), Map: mapPath}}
+	if *mapFile == "" {
+		var err error
+		if levels, err = game.LoadLevels(assets); err != nil {
+			fmt.Println("failed to load levels:", err)
+			os.Exit(2)
+		}
+	}
+
+	gameObj := game.NewGame(assets, mapFS, levels)
+	// edits are saved next to the map they were made to
+	gameObj.MapDir = mapDir
 
-	err := ebiten.RunGame(gameObj)
+	// the window is opened first so that it loads the images of any level
+	// the game moves to from here on
+	var gameWindow window
+	if *headless == 0 && !(*replay != "" && *verify) {
+		var err error
+		if gameWindow, err = openWindow(gameObj); err != nil {
+			fmt.Println("failed to open window:", err)
+			os.Exit(2)
+		}
+	}
+
+	if *load != "" {
+		if err := gameObj.LoadGame(*load); err != nil {
+			fmt.Println("failed to load game:", err)
+			os.Exit(1)
+		}
+	}
+
+	if *replay != "" {
+		if err := startReplay(gameObj); err != nil {
+			fmt.Println("failed to replay:", err)
+			os.Exit(1)
+		}
+		if *verify {
+			return
+		}
+	}
+
+	if *headless > 0 {
+		if err := runHeadless(gameObj, *headless); err != nil {
+			fmt.Println("headless run failed:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	var recording *game.Recording
+	if *record != "" {
+		var err error
+		if recording, err = game.StartRecording(gameObj); err != nil {
+			fmt.Println("failed to record:", err)
+			os.Exit(1)
+		}
+	}
+
+	// the recording is saved whether the game ends well or not, it's most
+	// useful when it doesn't
+	err := gameWindow.Run()
 	if err != nil {
 		fmt.Println("failed to run game:", err)
 	}
+	if recording != nil {
+		if err := recording.Save(*record); err != nil {
+			fmt.Println("failed to save recording:", err)
+		} else {
+			fmt.Printf("saved recording of %d ticks to %s\n", len(recording.Checksums), *record)
+		}
+	}
+	if err != nil {
+		os.Exit(1)
+	}
+}
+
+// window shows the game until it is closed.
+type window interface {
+	Run() error
+}
+
+// startReplay makes the game play the recording given by -replay. With
+// -verify the whole recording is played straight away without a window.
+func startReplay(g *game.Game) error {
+	recording, err := game.LoadRecording(*replay)
+	if err != nil {
+		return err
+	}
+	r, err := game.StartReplay(g, recording)
+	if err != nil {
+		return err
+	}
+	if !*verify {
+		return nil
+	}
+
+	runner := &game.Runner{Game: g, Script: r.Script}
+	if _, _, err := runner.RunUntil(recording.Frames, nil); err != nil {
+		return err
+	}
+	if r.Diverged != 0 {
+		return fmt.Errorf("diverged from the recording at tick %d", r.Diverged)
+	}
+	fmt.Printf("replay matched the recording for all %d ticks\n", len(recording.Checksums))
+	return nil
+}
+
+// openAssets returns the file systems to load assets and the map from, along
+// with the directory on disk the map's file system is opened from and the
+// path of the map within it. Anything not given on the command line comes
+// from the assets built into the binary, which have no directory.
+func openAssets() (assets fs.FS, mapFS fs.FS, mapDir, mapPath string) {
+	assets, err := fs.Sub(EmbeddedAssets, "assets")
+	if err != nil {
+		panic(err)
+	}
+	if *assetsDir != "" {
+		assets, mapDir = os.DirFS(*assetsDir), *assetsDir
+	}
+
+	mapFS, mapPath = assets, "map.tmx"
+	if *mapFile != "" {
+		if mapFS, mapDir, mapPath, err = game.OpenMap(*mapFile); err != nil {
+			fmt.Println("failed to open map:", err)
+			os.Exit(2)
+		}
+	}
+	return assets, mapFS, mapDir, mapPath
+}
+
+func runHeadless(g *game.Game, frames int) error {
+	inputScript := input.NewScript(nil)
+	if *script != "" {
+		file, err := os.Open(*script)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		if inputScript, err = input.ParseScript(file); err != nil {
+			return fmt.Errorf("%s: %w", *script, err)
+		}
+	}
+	g.SetSeed(*seed)
+
+	var done func(g *game.Game) bool
+	if *caught {
+		done = game.ChickensAtPlayer
+	}
+	runner := game.NewRunner(g, inputScript)
+	if *captureTo != "" {
+		runner.Capture = capture.New(utils.CaptureEvery, utils.CaptureMaxFrames, 100*utils.CaptureEvery/utils.TicksPerSecond)
+	}
+	ran, reached, err := runner.RunUntil(frames, done)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("ran %d frames\n", ran)
+	if runner.Capture != nil {
+		if err := runner.Capture.Save(*captureTo); err != nil {
+			return err
+		}
+		fmt.Printf("captured %d frames to %s\n", runner.Capture.Len(), *captureTo)
+	}
+	game.WriteState(os.Stdout, g)
+	if *caught && !reached {
+		return fmt.Errorf("chickens not all on the player's cell after %d frames", frames)
+	}
+	return nil
+}
+
+func runComparison(mapFS fs.FS, mapPath string, n int, seed int64) error {
+	gameMap, err := tiled.LoadFile(mapPath, tiled.WithFileSystem(mapFS))
+	if err != nil {
+		return err
+	}
+	gridMap := astar.NewGridMap(gameMap)
+	pairs := astar.RandomPairs(gridMap, n, rand.New(rand.NewSource(seed)))
+	return astar.WriteComparisonTable(os.Stdout, astar.Compare(gridMap, pairs))
+}
+
+// runExport exports the search given by -from, -to, -search and -heuristic on
+// the map to the file given by -export.
+func runExport(mapFS fs.FS, mapPath string) error {
+	args, err := parseSearchArgs(mapFS, mapPath)
+	if err != nil {
+		return err
+	}
+	e := export.New(args.gridMap, args.origin, args.dest, args.algorithm, args.heuristic)
+	if err := e.Save(*exportTo); err != nil {
+		return err
+	}
+	fmt.Printf("exported %s from %d,%d to %d,%d to %s\n",
+		args.algorithm.Name, args.origin.X, args.origin.Y, args.dest.X, args.dest.Y, *exportTo)
+	return nil
+}
+
+// runCapture draws the search given by -from, -to, -search and -heuristic
+// after every expansion and saves the frames to the file given by -capture.
+// Only the weighted best-first searches can be stepped, A* stands in for the
+// others.
+func runCapture(mapFS fs.FS, mapPath string) error {
+	args, err := parseSearchArgs(mapFS, mapPath)
+	if err != nil {
+		return err
+	}
+	algorithm := args.algorithm
+	if algorithm.GWeight == 0 && algorithm.HWeight == 0 {
+		algorithm = astar.Algorithms[0]
+	}
+	options := astar.DefaultSearchOptions
+	options.Heuristic = args.heuristic.Func
+	newSearch := func() *astar.Search {
+		return astar.NewSearchWithOptions(args.gridMap, args.origin, args.dest, algorithm.GWeight, algorithm.HWeight, options)
+	}
+
+	// search once first to spread the frames over the whole search
+	dryRun := newSearch()
+	dryRun.Run()
+	every := dryRun.Expanded/utils.CaptureMaxFrames + 1
+
+	s := newSearch()
+	c := capture.New(every, utils.CaptureMaxFrames, 5)
+	for !s.Step() {
+		c.Frame(func() image.Image { return capture.Search(s, utils.CaptureCellSize) })
+	}
+	c.MaxFrames += 1 // the result always gets a frame
+	c.Add(capture.Search(s, utils.CaptureCellSize))
+	if err := c.Save(*captureTo); err != nil {
+		return err
+	}
+	fmt.Printf("captured %s from %d,%d to %d,%d in %d frames to %s\n",
+		algorithm.Name, args.origin.X, args.origin.Y, args.dest.X, args.dest.Y, c.Len(), *captureTo)
+	return nil
+}
+
+// searchArgs is the search given by -from, -to, -search and -heuristic.
+type searchArgs struct {
+	gridMap   *astar.GridMap
+	origin    *astar.Cell
+	dest      *astar.Cell
+	algorithm astar.Algorithm
+	heuristic astar.NamedHeuristic
+}
+
+func parseSearchArgs(mapFS fs.FS, mapPath string) (*searchArgs, error) {
+	gameMap, err := tiled.LoadFile(mapPath, tiled.WithFileSystem(mapFS))
+	if err != nil {
+		return nil, err
+	}
+	args := &searchArgs{gridMap: astar.NewGridMap(gameMap)}
+
+	found := false
+	for _, algorithm := range astar.Algorithms {
+		if algorithm.Name == *search {
+			args.algorithm, found = algorithm, true
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("no search algorithm called %q", *search)
+	}
+	if !args.algorithm.Searches(args.gridMap) {
+		return nil, fmt.Errorf("%s only searches maps whose cells all cost the same", args.algorithm.Name)
+	}
+	found = false
+	for _, namedHeuristic := range astar.Heuristics {
+		if namedHeuristic.Name == *heuristic {
+			args.heuristic, found = namedHeuristic, true
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("no heuristic called %q", *heuristic)
+	}
+
+	pair := astar.RandomPairs(args.gridMap, 1, rand.New(rand.NewSource(*seed)))
+	if pair == nil {
+		return nil, fmt.Errorf("no walkable cells on the map")
+	}
+	args.origin, args.dest = pair[0][0], pair[0][1]
+	if *from != "" {
+		if args.origin, err = parseCell(args.gridMap, *from); err != nil {
+			return nil, err
+		}
+	}
+	if *to != "" {
+		if args.dest, err = parseCell(args.gridMap, *to); err != nil {
+			return nil, err
+		}
+	}
+	return args, nil
+}
+
+// parseCell returns the cell of m at "x,y".
+func parseCell(m *astar.GridMap, s string) (*astar.Cell, error) {
+	var x, y int
+	if _, err := fmt.Sscanf(s, "%d,%d", &x, &y); err != nil {
+		return nil, fmt.Errorf("bad cell %q, expected x,y", s)
+	}
+	cell := m.GetGridCell(x, y)
+	if cell == nil {
+		return nil, fmt.Errorf("cell %d,%d is outside the map", x, y)
+	}
+	return cell, nil
 }
@@ -0,0 +1,173 @@
+package astar
+
+import (
+	"a-star/src/utils"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// A grid map in ASCII has one line per row and one character per cell:
+//
+//	#######
+//	#S..3.#
+//	#.##9.#
+//	#....G#
+//	#######
+//
+// A . is a walkable cell costing 1, a digit from 1 to 9 a walkable cell
+// costing that much and a # a wall. S marks the origin and G the goal, both
+// on walkable cells costing 1. FormatGrid writes maps the same way, so they
+// read back exactly.
+//
+// A path is drawn apart from the map by FormatPath, with a * on each of its
+// cells over the walls and the markers only, since it would hide their costs.
+const (
+	asciiWalkable = '.'
+	asciiWall     = '#'
+	asciiOrigin   = 'S'
+	asciiGoal     = 'G'
+	asciiPath     = '*'
+)
+
+// ParseGrid reads a grid map in ASCII, along with its origin and goal cells
+// if it marks them. Blank lines and the whitespace around each row are
+// skipped, so maps can be indented inside Go string literals.
+func ParseGrid(s string) (m *GridMap, origin, goal *Cell, err error) {
+	m = &GridMap{CellWidth: utils.UnitSize, CellHeight: utils.UnitSize}
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		y := len(m.Cells)
+		if y > 0 && len(line) != len(m.Cells[0]) {
+			return nil, nil, nil, fmt.Errorf("row %d is %d cells wide, not %d", y, len(line), len(m.Cells[0]))
+		}
+
+		row := []*Cell{}
+		for x, c := range []byte(line) {
+			cell := &Cell{X: x, Y: y, Cost: 1, IsWalkable: true}
+			switch {
+			case c == asciiWall:
+				cell.IsWalkable = false
+			case c >= '1' && c <= '9':
+				cell.Cost = float64(c - '0')
+			case c == asciiOrigin:
+				if origin != nil {
+					return nil, nil, nil, fmt.Errorf("more than one %c at %d,%d", c, x, y)
+				}
+				origin = cell
+			case c == asciiGoal:
+				if goal != nil {
+					return nil, nil, nil, fmt.Errorf("more than one %c at %d,%d", c, x, y)
+				}
+				goal = cell
+			case c != asciiWalkable:
+				return nil, nil, nil, fmt.Errorf("unknown cell %q at %d,%d", c, x, y)
+			}
+			row = append(row, cell)
+		}
+		m.Cells = append(m.Cells, row)
+	}
+	if len(m.Cells) == 0 {
+		return nil, nil, nil, fmt.Errorf("no rows in the grid")
+	}
+	m.Width = len(m.Cells[0])
+	m.Height = len(m.Cells)
+	return m, origin, goal, nil
+}
+
+// FormatGrid writes m in ASCII with origin and goal marked, either of them can
+// be nil. It fails rather than write a map that wouldn't read back the same:
+// one with a cost that isn't a whole number from 1 to 9, or a marker on a wall
+// or on a cell that costs more than 1.
+func FormatGrid(m *GridMap, origin, goal *Cell) (string, error) {
+	for y := range m.Cells {
+		for _, cell := range m.Cells[y] {
+			if cell.IsWalkable && (cell.Cost < 1 || cell.Cost > 9 || cell.Cost != math.Trunc(cell.Cost)) {
+				return "", fmt.Errorf("cost %g at %d,%d can't be written", cell.Cost, cell.X, cell.Y)
+			}
+		}
+	}
+	rows := formatCells(m)
+	for _, marker := range []struct {
+		cell *Cell
+		c    byte
+	}{{origin, asciiOrigin}, {goal, asciiGoal}} {
+		if marker.cell == nil {
+			continue
+		}
+		if rows[marker.cell.Y][marker.cell.X] != asciiWalkable {
+			return "", fmt.Errorf("%c at %d,%d would hide its cell", marker.c, marker.cell.X, marker.cell.Y)
+		}
+		rows[marker.cell.Y][marker.cell.X] = marker.c
+	}
+	return joinRows(rows), nil
+}
+
+// FormatPath draws path over the walls of m, with origin and goal marked. Any
+// of them can be nil. Costs are left out, the map is written on its own by
+// FormatGrid.
+func FormatPath(m *GridMap, origin, goal *Cell, path *Path) string {
+	rows := [][]byte{}
+	for y := range m.Cells {
+		row := []byte{}
+		for _, cell := range m.Cells[y] {
+			if cell.IsWalkable {
+				row = append(row, asciiWalkable)
+			} else {
+				row = append(row, asciiWall)
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	mark := func(cell *Cell, c byte) {
+		if cell != nil && m.GetGridCell(cell.X, cell.Y) != nil {
+			rows[cell.Y][cell.X] = c
+		}
+	}
+	if path != nil {
+		for _, cell := range path.Cells {
+			mark(cell, asciiPath)
+		}
+	}
+	mark(origin, asciiOrigin)
+	mark(goal, asciiGoal)
+	return joinRows(rows)
+}
+
+// formatCells writes the walls and costs of m, costs rounded to a whole number
+// from 1 to 9.
+func formatCells(m *GridMap) [][]byte {
+	rows := [][]byte{}
+	for y := range m.Cells {
+		row := []byte{}
+		for _, cell := range m.Cells[y] {
+			row = append(row, asciiCell(cell))
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func joinRows(rows [][]byte) string {
+	var s strings.Builder
+	for _, row := range rows {
+		s.Write(row)
+		s.WriteByte('\n')
+	}
+	return s.String()
+}
+
+func asciiCell(cell *Cell) byte {
+	if !cell.IsWalkable {
+		return asciiWall
+	}
+	cost := int(math.Round(math.Max(1, math.Min(cell.Cost, 9))))
+	if cost == 1 {
+		return asciiWalkable
+	}
+	return byte('0' + cost)
+}
@@ -0,0 +1,119 @@
+package astar
+
+import (
+	"strings"
+	"testing"
+)
+
+// costlyGrid has costs, walls, a dead end and a cell walled off from the rest.
+const costlyGrid = `
+	##########
+	#S..3...##
+	#.##9#.#.#
+	#..2...#.#
+	###.####.#
+	#...5....#
+	#.#####2.#
+	#......#G#
+	#####.####
+	#...#.#.##
+	##########
+`
+
+// parseTestGrid reads s, failing the test if it can't.
+func parseTestGrid(t testing.TB, s string) (m *GridMap, origin, goal *Cell) {
+	t.Helper()
+	m, origin, goal, err := ParseGrid(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m, origin, goal
+}
+
+// trimGrid is s the way FormatGrid writes it, without blank lines or
+// indentation.
+func trimGrid(s string) string {
+	var rows strings.Builder
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			rows.WriteString(line + "\n")
+		}
+	}
+	return rows.String()
+}
+
+func TestGridRoundTrip(t *testing.T) {
+	for _, grid := range []string{costlyGrid, "S\n", "9#\n#.\n", "...\n.G.\n"} {
+		m, origin, goal := parseTestGrid(t, grid)
+		s, err := FormatGrid(m, origin, goal)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := trimGrid(grid); s != want {
+			t.Fatalf("got\n%swant\n%s", s, want)
+		}
+
+		again, againOrigin, againGoal := parseTestGrid(t, s)
+		if again.Width != m.Width || again.Height != m.Height || (againOrigin == nil) != (origin == nil) || (againGoal == nil) != (goal == nil) {
+			t.Fatalf("%s read back as another grid", s)
+		}
+		for y := range m.Cells {
+			for x, cell := range m.Cells[y] {
+				if c := again.Cells[y][x]; c.IsWalkable != cell.IsWalkable || c.Cost != cell.Cost {
+					t.Fatalf("cell %d,%d read back as %+v, not %+v", x, y, *c, *cell)
+				}
+			}
+		}
+	}
+}
+
+func TestFormatGridErrors(t *testing.T) {
+	m, _, _ := parseTestGrid(t, "#.3\n...\n")
+	tests := []struct {
+		name         string
+		change       func()
+		origin, goal *Cell
+	}{
+		{"fractional cost", func() { m.Cells[1][0].Cost = 1.5 }, nil, nil},
+		{"cost over 9", func() { m.Cells[1][0].Cost = 10 }, nil, nil},
+		{"origin on a wall", func() {}, m.Cells[0][0], nil},
+		{"goal on a cost", func() {}, nil, m.Cells[0][2]},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m.Cells[1][0].Cost = 1
+			test.change()
+			if s, err := FormatGrid(m, test.origin, test.goal); err == nil {
+				t.Fatalf("wrote\n%s", s)
+			}
+		})
+	}
+}
+
+func TestParseGridErrors(t *testing.T) {
+	for _, grid := range []string{"", "..\n...\n", ".x.\n", "S.S\n", "G\nG\n", ".*.\n"} {
+		if _, _, _, err := ParseGrid(grid); err == nil {
+			t.Errorf("%q parsed", grid)
+		}
+	}
+}
+
+func TestFormatPath(t *testing.T) {
+	m, origin, goal := parseTestGrid(t, `
+		#######
+		#S.#..#
+		##.#.##
+		##...G#
+		#######
+	`)
+	want := trimGrid(`
+		#######
+		#S*#..#
+		##*#.##
+		##***G#
+		#######
+	`)
+	if s := FormatPath(m, origin, goal, AStar(m, origin, goal)); s != want {
+		t.Fatalf("got\n%swant\n%s", s, want)
+	}
+}
@@ -27,102 +27,247 @@ type Node struct {
 	f      float64 // total cost
 	g      float64 // distance between current node and origin node
 	h      float64 // heuristic
+	cross  float64 // distance from the straight line between origin and dest
+	order  int     // position in which the node was pushed onto the queue
+}
+
+// Expansion is a node as a Search took it off the open queue.
+type Expansion struct {
+	Cell *Cell
+	G    float64
+	H    float64
+	F    float64
 }
 
 type GridMap struct {
-	Cells      [][]*Cell
-	Width      int
-	Height     int
-	CellWidth  int
-	CellHeight int
+	Cells       [][]*Cell
+	Width       int
+	Height      int
+	CellWidth   int
+	CellHeight  int
+	obstacles   map[*Cell][]*Obstacle
+	base        map[*Cell]cellState
+	subscribers []ChangeFunc
+}
+
+// TieBreak decides which of two open nodes with the same f is expanded first.
+type TieBreak int
+
+const (
+	TieBreakNone  TieBreak = iota // whichever the heap returns first, which depends on the order of pushes
+	TieBreakHighG                 // prefer the node furthest from the origin
+	TieBreakLowH                  // prefer the node closest to the dest, the default
+	TieBreakFIFO                  // prefer the node pushed first
+	TieBreakLIFO                  // prefer the node pushed last
+)
+
+type SearchOptions struct {
+	TieBreak     TieBreak
+	CrossProduct bool          // among equal f, prefer nodes on the straight line between origin and dest
+	Heuristic    HeuristicFunc // nil for Heuristic
+	NodeLimit    int           // most nodes IDA* and SMA* keep in memory
+
+	// Trace, if set, is called with every node a search expands, in order.
+	// Searches that expand a cell more than once report it each time.
+	Trace func(Expansion)
+}
+
+// DefaultSearchOptions are used by AStar and every search created with NewSearch.
+// Ties go to the node closest to the dest, then to the one pushed first, so
+// the same search always finds the same path.
+var DefaultSearchOptions = SearchOptions{TieBreak: TieBreakLowH, NodeLimit: utils.SearchNodeLimit}
+
+// HeuristicFunc estimates the cost of travelling from cell to destCell.
+type HeuristicFunc func(cell, destCell *Cell) float64
+
+// Search is a best-first search over a GridMap that can be advanced one
+// expansion at a time. Nodes are ordered by f = GWeight*g + HWeight*h, so A*,
+// Dijkstra and greedy best-first only differ in their weights.
+type Search struct {
+	Map        *GridMap
+	Origin     *Cell
+	Dest       *Cell // nil when searching for a set of goals
+	Goal       Goal
+	Reached    *Cell // goal cell the path ends at, nil until found
+	Heuristic  HeuristicFunc
+	GWeight    float64
+	HWeight    float64
+	Expanded   int   // number of nodes taken off the open queue
+	Path       *Path // result, nil until found
+	Done       bool
+	Trace      bool        // record every expansion in Expansions
+	Expansions []Expansion // in the order the nodes were expanded
+	trace      func(Expansion)
+	open       PriorityQueue
+	closed     []*Node
 }
 
 func AStar(m *GridMap, originCell, destCell *Cell) (path *Path) {
-	var open PriorityQueue
-	closed := []*Node{}
+	return NewSearch(m, originCell, destCell, 1, 1).Run()
+}
+
+func AStarWithOptions(m *GridMap, originCell, destCell *Cell, options SearchOptions) (path *Path) {
+	return NewSearchWithOptions(m, originCell, destCell, 1, 1, options).Run()
+}
+
+// AStarToGoal finds the cheapest path to any cell of goal and returns it along
+// with the goal cell that was reached.
+func AStarToGoal(m *GridMap, originCell *Cell, goal Goal) (path *Path, reached *Cell) {
+	s := NewGoalSearch(m, originCell, goal, 1, 1, DefaultSearchOptions)
+	return s.Run(), s.Reached
+}
+
+func Dijkstra(m *GridMap, originCell, destCell *Cell) (path *Path) {
+	return NewSearch(m, originCell, destCell, 1, 0).Run()
+}
+
+func GreedyBestFirst(m *GridMap, originCell, destCell *Cell) (path *Path) {
+	return NewSearch(m, originCell, destCell, 0, 1).Run()
+}
+
+func NewSearch(m *GridMap, originCell, destCell *Cell, gWeight, hWeight float64) *Search {
+	return NewSearchWithOptions(m, originCell, destCell, gWeight, hWeight, DefaultSearchOptions)
+}
+
+func NewSearchWithOptions(m *GridMap, originCell, destCell *Cell, gWeight, hWeight float64, options SearchOptions) *Search {
+	if cell := m.GetGridCell(destCell.X, destCell.Y); cell != nil {
+		destCell = cell
+	}
+	s := NewGoalSearch(m, originCell, GoalCells{destCell}, gWeight, hWeight, options)
+	s.Dest = destCell
+	return s
+}
+
+func NewGoalSearch(m *GridMap, originCell *Cell, goal Goal, gWeight, hWeight float64, options SearchOptions) *Search {
+	// use the map's own cells so nodes can be compared by pointer
+	if cell := m.GetGridCell(originCell.X, originCell.Y); cell != nil {
+		originCell = cell
+	}
+
+	s := &Search{
+		Map:       m,
+		Origin:    originCell,
+		Goal:      goal,
+		Heuristic: options.heuristic(),
+		GWeight:   gWeight,
+		HWeight:   hWeight,
+		trace:     options.Trace,
+	}
+	s.open.TieBreak = options.TieBreak
+	s.open.CrossProduct = options.CrossProduct
 
 	// create origin node and add to the open queuee
 	originNode := &Node{
 		Cell: originCell,
-		g:    originCell.Cost,
-		h:    Heuristic(originCell, destCell),
+		h:    s.Goal.Estimate(originCell, s.Heuristic),
 	}
-	originNode.f = originNode.g + originNode.h
+	originNode.f = s.GWeight*originNode.g + s.HWeight*originNode.h
 	originNode.Parent = originNode
-	heap.Push(&open, originNode)
-
-	// repeat until there are no more open nodes to check
-	for len(open) > 0 {
-		q := heap.Pop(&open).(*Node)
-
-		// if destination has been reached, reconstruct path and return
-		if q.Cell.X == destCell.X && q.Cell.Y == destCell.Y {
-			path := &Path{}
-			for q.Cell != originCell {
-				path.Cells = append(path.Cells, q.Cell)
-				q = q.Parent
-			}
-			path.Reverse()
-			return path
-		}
+	heap.Push(&s.open, originNode)
 
-		var cell *Cell
+	return s
+}
 
-		// add neighboring nodes to the open queue
-		// cell to the left of q
-		cell = m.GetGridCell(q.Cell.X-1, q.Cell.Y)
-		if cell != nil && cell.IsWalkable {
-			AddNeighboringNode(cell, destCell, q, &closed, &open)
-		}
+// Run steps the search until it finishes and returns the path found, if any.
+func (s *Search) Run() *Path {
+	for !s.Step() {
+	}
+	return s.Path
+}
 
-		// cell to the right of q
-		cell = m.GetGridCell(q.Cell.X+1, q.Cell.Y)
-		if cell != nil && cell.IsWalkable {
-			AddNeighboringNode(cell, destCell, q, &closed, &open)
-		}
+// Step expands the next open node and reports whether the search has finished.
+func (s *Search) Step() bool {
+	if s.Done {
+		return true
+	}
+	if s.open.Len() == 0 {
+		// there are no more open nodes to check
+		s.Done = true
+		return true
+	}
 
-		// cell below q
-		cell = m.GetGridCell(q.Cell.X, q.Cell.Y-1)
-		if cell != nil && cell.IsWalkable {
-			AddNeighboringNode(cell, destCell, q, &closed, &open)
-		}
+	q := heap.Pop(&s.open).(*Node)
+	s.Expanded += 1
+	if s.Trace {
+		s.Expansions = append(s.Expansions, Expansion{Cell: q.Cell, G: q.g, H: q.h, F: q.f})
+	}
+	if s.trace != nil {
+		s.trace(Expansion{Cell: q.Cell, G: q.g, H: q.h, F: q.f})
+	}
 
-		// cell above q
-		cell = m.GetGridCell(q.Cell.X, q.Cell.Y+1)
-		if cell != nil && cell.IsWalkable {
-			AddNeighboringNode(cell, destCell, q, &closed, &open)
+	// if destination has been reached, reconstruct path and return
+	if s.Goal.Reached(q.Cell) {
+		s.Reached = q.Cell
+		path := &Path{}
+		for q.Cell != s.Origin {
+			path.Cells = append(path.Cells, q.Cell)
+			q = q.Parent
 		}
+		path.Reverse()
+		s.Path = path
+		s.Done = true
+		return true
+	}
 
-		closed = append(closed, q)
+	// add neighboring nodes to the open queue
+	for _, cell := range s.Map.Neighbors(q.Cell) {
+		s.addNeighboringNode(cell, q)
 	}
 
-	return nil
+	s.closed = append(s.closed, q)
+	return false
 }
 
-func AddNeighboringNode(cell, destCell *Cell, q *Node, closed *[]*Node, open *PriorityQueue) {
+// OpenCells returns the cells waiting to be expanded.
+func (s *Search) OpenCells() []*Cell {
+	cells := []*Cell{}
+	for _, n := range s.open.Nodes {
+		cells = append(cells, n.Cell)
+	}
+	return cells
+}
+
+// ClosedCells returns the cells already expanded.
+func (s *Search) ClosedCells() []*Cell {
+	cells := []*Cell{}
+	for _, n := range s.closed {
+		cells = append(cells, n.Cell)
+	}
+	return cells
+}
+
+func (s *Search) addNeighboringNode(cell *Cell, q *Node) {
 	n := &Node{
 		Cell:   cell,
 		Parent: q,
 		g:      q.g + cell.Cost,
-		h:      Heuristic(cell, destCell),
+		h:      s.Goal.Estimate(cell, s.Heuristic),
+	}
+	n.f = s.GWeight*n.g + s.HWeight*n.h
+	if s.Dest != nil {
+		n.cross = crossProduct(cell, s.Origin, s.Dest)
 	}
-	n.f = n.g + n.h
 
-	if !Contains(*closed, n.Cell) && !Contains(*open, n.Cell) {
-		heap.Push(open, n)
-	} else if Contains(*open, n.Cell) && n.g > q.g+n.Cell.Cost {
+	if i := IndexOf(s.open.Nodes, n.Cell); i >= 0 {
 		// if current cost is better than previous cost, change to current path
-		openNode := GetFromList(*open, n.Cell)
-		openNode.Parent = n.Parent
-		openNode.f = n.f
-		openNode.g = n.g
-		openNode.h = n.h
-	} else if Contains(*closed, n.Cell) && n.g > q.g+n.Cell.Cost {
+		openNode := s.open.Nodes[i]
+		if n.g < openNode.g {
+			openNode.Parent = n.Parent
+			openNode.f = n.f
+			openNode.g = n.g
+			openNode.h = n.h
+			heap.Fix(&s.open, i)
+		}
+	} else if closedNode := GetFromList(s.closed, n.Cell); closedNode != nil {
 		// if current cost is better than previous cost, revisit node
-		*closed = Remove(*closed, n.Cell)
+		if n.g < closedNode.g {
+			s.closed = Remove(s.closed, n.Cell)
 
-		// add to open list
-		heap.Push(open, n)
+			// add to open list
+			heap.Push(&s.open, n)
+		}
+	} else {
+		heap.Push(&s.open, n)
 	}
 }
 
@@ -131,6 +276,13 @@ func Heuristic(cell, destCell *Cell) float64 {
 	return math.Abs(float64(destCell.X-cell.X)) + math.Abs(float64(destCell.Y-cell.Y))
 }
 
+func crossProduct(cell, originCell, destCell *Cell) float64 {
+	// how far cell strays from the line between origin and dest
+	dx1, dy1 := cell.X-destCell.X, cell.Y-destCell.Y
+	dx2, dy2 := originCell.X-destCell.X, originCell.Y-destCell.Y
+	return math.Abs(float64(dx1*dy2 - dx2*dy1))
+}
+
 func (p *Path) GetCurrentCell() *Cell {
 	if p.CurrentCell >= len(p.Cells) {
 		return nil
@@ -142,18 +294,29 @@ func (p *Path) Next() {
 	p.CurrentCell += 1
 }
 
+// Cost returns the total cost of the cells along the path.
+func (p *Path) Cost() float64 {
+	cost := 0.0
+	for _, cell := range p.Cells {
+		cost += cell.Cost
+	}
+	return cost
+}
+
 func NewGridMap(gameMap *tiled.Map) *GridMap {
 	gridMap := &GridMap{CellWidth: gameMap.TileWidth, CellHeight: gameMap.TileHeight}
 
 	mapTiles := gameMap.Layers[utils.CollisionLayer].Tiles
+	groundTiles := gameMap.Layers[utils.GroundLayer].Tiles
 	for tileY := 0; tileY < gameMap.Height; tileY++ {
 		cellRow := []*Cell{}
 		for tileX := 0; tileX < gameMap.Width; tileX++ {
 			tile := mapTiles[tileY*gameMap.Width+tileX]
+			cost := TileCost(groundTiles[tileY*gameMap.Width+tileX])
 			if tile.IsNil() {
-				cellRow = append(cellRow, &Cell{X: tileX, Y: tileY, Cost: 1, IsWalkable: true})
+				cellRow = append(cellRow, &Cell{X: tileX, Y: tileY, Cost: cost, IsWalkable: true})
 			} else {
-				cellRow = append(cellRow, &Cell{X: tileX, Y: tileY, Cost: 1, IsWalkable: false})
+				cellRow = append(cellRow, &Cell{X: tileX, Y: tileY, Cost: cost, IsWalkable: false})
 			}
 		}
 		gridMap.Cells = append(gridMap.Cells, cellRow)
@@ -164,6 +327,22 @@ func NewGridMap(gameMap *tiled.Map) *GridMap {
 	return gridMap
 }
 
+// TileCost returns the cost of crossing a ground tile, set with a "cost"
+// property on the tile in its tileset. Tiles without one cost 1.
+func TileCost(tile *tiled.LayerTile) float64 {
+	if tile.IsNil() || tile.Tileset == nil {
+		return 1
+	}
+	tilesetTile, err := tile.Tileset.GetTilesetTile(tile.ID)
+	if err != nil {
+		return 1
+	}
+	if cost := tilesetTile.Properties.GetFloat("cost"); cost > 0 {
+		return cost
+	}
+	return 1
+}
+
 func (m *GridMap) PrintMap() {
 	for y := range m.Cells {
 		for _, cell := range m.Cells[y] {
@@ -173,24 +352,46 @@ func (m *GridMap) PrintMap() {
 	}
 }
 
+// PrintCost prints the walls and costs of the map in ASCII like FormatGrid,
+// with costs rounded to a whole number from 1 to 9.
 func (m *GridMap) PrintCost() {
+	fmt.Print(joinRows(formatCells(m)))
+}
+
+func (m *GridMap) GetGridCell(x, y int) *Cell {
+	if x < 0 || x >= m.Width || y < 0 || y >= m.Height {
+		return nil
+	}
+	return m.Cells[y][x]
+}
+
+// MinCost returns the least any walkable cell of m costs to step into, 0 if
+// none is walkable.
+func (m *GridMap) MinCost() float64 {
+	cost := math.Inf(1)
 	for y := range m.Cells {
 		for _, cell := range m.Cells[y] {
 			if cell.IsWalkable {
-				fmt.Print("0 ")
-			} else {
-				fmt.Print("1 ")
+				cost = math.Min(cost, cell.Cost)
 			}
 		}
-		fmt.Println("")
 	}
+	if math.IsInf(cost, 1) {
+		return 0
+	}
+	return cost
 }
 
-func (m *GridMap) GetGridCell(x, y int) *Cell {
-	if x < 0 || x >= m.Width || y < 0 || y >= m.Height {
-		return nil
+func (m *GridMap) Neighbors(cell *Cell) []*Cell {
+	// walkable cells to the left, right, below and above of cell
+	neighbors := []*Cell{}
+	for _, d := range [][2]int{{-1, 0}, {1, 0}, {0, -1}, {0, 1}} {
+		n := m.GetGridCell(cell.X+d[0], cell.Y+d[1])
+		if n != nil && n.IsWalkable {
+			neighbors = append(neighbors, n)
+		}
 	}
-	return m.Cells[y][x]
+	return neighbors
 }
 
 func GetCell(x, y int) (cell *Cell) {
@@ -210,32 +411,69 @@ func (p *Path) Reverse() {
 
 // Priority Queue
 // - implement the priority queue as a min heap
-type PriorityQueue []*Node
+// - nodes with equal f are ordered by the queue's tie-breaking rule
+type PriorityQueue struct {
+	Nodes        []*Node
+	TieBreak     TieBreak
+	CrossProduct bool // prefer nodes closer to the straight line between origin and dest
+	pushed       int
+}
 
-func (q PriorityQueue) Len() int { return len(q) }
+func (q PriorityQueue) Len() int { return len(q.Nodes) }
 
 func (p PriorityQueue) Less(i, j int) bool {
 	// Pop returns the lowest
-	return p[i].f < p[j].f
+	a, b := p.Nodes[i], p.Nodes[j]
+	if !almostEqual(a.f, b.f) {
+		return a.f < b.f
+	}
+	if p.CrossProduct && !almostEqual(a.cross, b.cross) {
+		return a.cross < b.cross
+	}
+
+	switch p.TieBreak {
+	case TieBreakHighG:
+		if !almostEqual(a.g, b.g) {
+			return a.g > b.g
+		}
+		return a.order < b.order
+	case TieBreakLowH:
+		if !almostEqual(a.h, b.h) {
+			return a.h < b.h
+		}
+		return a.order < b.order
+	case TieBreakFIFO:
+		return a.order < b.order
+	case TieBreakLIFO:
+		return a.order > b.order
+	}
+	return false
 }
 
 func (p PriorityQueue) Swap(i, j int) {
-	p[i], p[j] = p[j], p[i]
+	p.Nodes[i], p.Nodes[j] = p.Nodes[j], p.Nodes[i]
 }
 
 func (q *PriorityQueue) Push(x any) {
-	*q = append(*q, x.(*Node))
+	n := x.(*Node)
+	n.order = q.pushed
+	q.pushed += 1
+	q.Nodes = append(q.Nodes, n)
 }
 
 func (q *PriorityQueue) Pop() any {
-	old := *q
+	old := q.Nodes
 	n := len(old)
 	item := old[n-1]
 	old[n-1] = nil // avoid memory leak
-	*q = old[0 : n-1]
+	q.Nodes = old[0 : n-1]
 	return item
 }
 
+func almostEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
 func Contains(nodeList []*Node, cell *Cell) bool {
 	for _, n := range nodeList {
 		if n.Cell == cell {
@@ -256,6 +494,15 @@ func Remove(nodeList []*Node, cell *Cell) []*Node {
 	return append(nodeList[:index], nodeList[index+1:]...)
 }
 
+func IndexOf(nodeList []*Node, cell *Cell) int {
+	for i, n := range nodeList {
+		if n.Cell == cell {
+			return i
+		}
+	}
+	return -1
+}
+
 func GetFromList(nodeList []*Node, cell *Cell) *Node {
 	for i, n := range nodeList {
 		if n.Cell == cell {
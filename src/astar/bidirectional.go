@@ -0,0 +1,131 @@
+package astar
+
+import (
+	"container/heap"
+	"math"
+)
+
+// bidirectionalFrontier is one half of a bidirectional search: a forward
+// frontier grows from the origin and a backward frontier from the destination.
+type bidirectionalFrontier struct {
+	open      PriorityQueue
+	g         map[*Cell]float64
+	parents   map[*Cell]*Cell
+	closed    map[*Cell]bool
+	target    *Cell
+	heuristic HeuristicFunc
+}
+
+func newBidirectionalFrontier(start, target *Cell, heuristic HeuristicFunc) *bidirectionalFrontier {
+	f := &bidirectionalFrontier{
+		g:         map[*Cell]float64{start: 0},
+		parents:   map[*Cell]*Cell{},
+		closed:    map[*Cell]bool{},
+		target:    target,
+		heuristic: heuristic,
+	}
+	h := heuristic(start, target)
+	heap.Push(&f.open, &Node{Cell: start, h: h, f: h})
+	return f
+}
+
+func (f *bidirectionalFrontier) top() float64 {
+	// skip entries that were superseded by a cheaper one
+	for f.open.Len() > 0 && (f.closed[f.open.Nodes[0].Cell] || f.open.Nodes[0].g > f.g[f.open.Nodes[0].Cell]) {
+		heap.Pop(&f.open)
+	}
+	if f.open.Len() == 0 {
+		return math.Inf(1)
+	}
+	return f.open.Nodes[0].f
+}
+
+func BidirectionalAStar(m *GridMap, originCell, destCell *Cell) (path *Path) {
+	path, _ = bidirectionalAStar(m, originCell, destCell, Heuristic, nil)
+	return path
+}
+
+// bidirectionalAStar traces the nodes of both frontiers, those of the backward
+// one with their g counted from the dest.
+func bidirectionalAStar(m *GridMap, originCell, destCell *Cell, heuristic HeuristicFunc, trace func(Expansion)) (path *Path, expanded int) {
+	if cell := m.GetGridCell(originCell.X, originCell.Y); cell != nil {
+		originCell = cell
+	}
+	if cell := m.GetGridCell(destCell.X, destCell.Y); cell != nil {
+		destCell = cell
+	}
+
+	forward := newBidirectionalFrontier(originCell, destCell, heuristic)
+	backward := newBidirectionalFrontier(destCell, originCell, heuristic)
+
+	// best known origin to dest cost and the cell where the frontiers meet
+	best := math.Inf(1)
+	var meeting *Cell
+	if originCell == destCell {
+		best, meeting = 0, originCell
+	}
+
+	for {
+		topForward, topBackward := forward.top(), backward.top()
+		// neither frontier can improve on the best path once either of their
+		// lowest f values reaches it
+		if math.Max(topForward, topBackward) >= best || math.IsInf(math.Min(topForward, topBackward), 1) {
+			break
+		}
+
+		// grow the frontier with the lower f value
+		isForward := topForward <= topBackward
+		current, other := forward, backward
+		if !isForward {
+			current, other = backward, forward
+		}
+
+		q := heap.Pop(&current.open).(*Node)
+		current.closed[q.Cell] = true
+		expanded += 1
+		if trace != nil {
+			trace(Expansion{Cell: q.Cell, G: q.g, H: q.h, F: q.f})
+		}
+
+		for _, cell := range m.Neighbors(q.Cell) {
+			// moving into a cell costs that cell's cost, so the backward
+			// frontier pays for the cell it is leaving instead
+			var g float64
+			if isForward {
+				g = q.g + cell.Cost
+			} else {
+				g = q.g + q.Cell.Cost
+			}
+
+			if prev, ok := current.g[cell]; ok && g >= prev {
+				continue
+			}
+			current.g[cell] = g
+			current.parents[cell] = q.Cell
+			delete(current.closed, cell)
+
+			h := current.heuristic(cell, current.target)
+			heap.Push(&current.open, &Node{Cell: cell, g: g, h: h, f: g + h})
+
+			if otherG, ok := other.g[cell]; ok && g+otherG < best {
+				best = g + otherG
+				meeting = cell
+			}
+		}
+	}
+
+	if meeting == nil {
+		return nil, expanded
+	}
+
+	// walk back from the meeting cell to the origin, then on to the destination
+	path = &Path{}
+	for cell := meeting; cell != originCell; cell = forward.parents[cell] {
+		path.Cells = append(path.Cells, cell)
+	}
+	path.Reverse()
+	for cell := backward.parents[meeting]; cell != nil; cell = backward.parents[cell] {
+		path.Cells = append(path.Cells, cell)
+	}
+	return path, expanded
+}
@@ -0,0 +1,180 @@
+package astar
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"text/tabwriter"
+	"time"
+)
+
+// Algorithm is a named search that also reports how many nodes it expanded.
+type Algorithm struct {
+	Name   string
+	Search func(m *GridMap, originCell, destCell *Cell, options SearchOptions) (path *Path, expanded int)
+
+	// weights of the Search doing the same, both 0 if the algorithm isn't one
+	GWeight float64
+	HWeight float64
+
+	// UniformCost is set for algorithms that only search maps whose walkable
+	// cells all cost the same, they find no path on other maps
+	UniformCost bool
+}
+
+// Algorithms lists every search available for comparison.
+var Algorithms = []Algorithm{
+	{Name: "A*", Search: weightedSearch(1, 1), GWeight: 1, HWeight: 1},
+	{Name: "Dijkstra", Search: weightedSearch(1, 0), GWeight: 1},
+	{Name: "Greedy best-first", Search: weightedSearch(0, 1), HWeight: 1},
+	{Name: "Bidirectional A*", Search: bidirectionalSearch},
+	{Name: "IDA*", Search: memoryBoundedSearch(idaStar)},
+	{Name: "SMA*", Search: memoryBoundedSearch(smaStar)},
+	{Name: "JPS", Search: jpsSearchFunc, UniformCost: true},
+}
+
+// Searches reports whether the algorithm can search m.
+func (a Algorithm) Searches(m *GridMap) bool {
+	if !a.UniformCost {
+		return true
+	}
+	_, ok := m.UniformCost()
+	return ok
+}
+
+// Comparison is the result of running one algorithm on one origin/dest pair.
+type Comparison struct {
+	Algorithm     string
+	Origin        *Cell
+	Dest          *Cell
+	Found         bool
+	Cost          float64
+	OptimalityGap float64 // how much more the path costs than the optimal one, as a fraction
+	Expanded      int
+	Duration      time.Duration
+}
+
+func weightedSearch(gWeight, hWeight float64) func(m *GridMap, originCell, destCell *Cell, options SearchOptions) (*Path, int) {
+	return func(m *GridMap, originCell, destCell *Cell, options SearchOptions) (*Path, int) {
+		s := NewSearchWithOptions(m, originCell, destCell, gWeight, hWeight, options)
+		return s.Run(), s.Expanded
+	}
+}
+
+func bidirectionalSearch(m *GridMap, originCell, destCell *Cell, options SearchOptions) (*Path, int) {
+	return bidirectionalAStar(m, originCell, destCell, options.heuristic(), options.Trace)
+}
+
+func memoryBoundedSearch(search func(m *GridMap, originCell, destCell *Cell, maxNodes int, heuristic HeuristicFunc, trace func(Expansion)) (*Path, int)) func(m *GridMap, originCell, destCell *Cell, options SearchOptions) (*Path, int) {
+	return func(m *GridMap, originCell, destCell *Cell, options SearchOptions) (*Path, int) {
+		return search(m, originCell, destCell, options.NodeLimit, options.heuristic(), options.Trace)
+	}
+}
+
+// Compare runs every algorithm that can search m on each origin/dest pair.
+func Compare(m *GridMap, pairs [][2]*Cell) []Comparison {
+	comparisons := []Comparison{}
+	for _, pair := range pairs {
+		// dijkstra always finds the optimal path, so use it as the reference
+		optimal := Dijkstra(m, pair[0], pair[1])
+
+		for _, algorithm := range Algorithms {
+			if !algorithm.Searches(m) {
+				continue
+			}
+			start := time.Now()
+			path, expanded := algorithm.Search(m, pair[0], pair[1], DefaultSearchOptions)
+			c := Comparison{
+				Algorithm: algorithm.Name,
+				Origin:    pair[0],
+				Dest:      pair[1],
+				Found:     path != nil,
+				Expanded:  expanded,
+				Duration:  time.Since(start),
+			}
+			if path != nil {
+				c.Cost = path.Cost()
+				if optimal != nil && optimal.Cost() > 0 {
+					c.OptimalityGap = (c.Cost - optimal.Cost()) / optimal.Cost()
+				}
+			}
+			comparisons = append(comparisons, c)
+		}
+	}
+	return comparisons
+}
+
+// RandomPairs picks n origin/dest pairs of walkable cells from m.
+func RandomPairs(m *GridMap, n int, rng *rand.Rand) [][2]*Cell {
+	walkable := []*Cell{}
+	for y := range m.Cells {
+		for _, cell := range m.Cells[y] {
+			if cell.IsWalkable {
+				walkable = append(walkable, cell)
+			}
+		}
+	}
+	if len(walkable) == 0 {
+		return nil
+	}
+
+	pairs := [][2]*Cell{}
+	for i := 0; i < n; i++ {
+		pairs = append(pairs, [2]*Cell{
+			walkable[rng.Intn(len(walkable))],
+			walkable[rng.Intn(len(walkable))],
+		})
+	}
+	return pairs
+}
+
+// WriteComparisonTable writes one row per comparison followed by a summary of
+// each algorithm over all pairs.
+func WriteComparisonTable(w io.Writer, comparisons []Comparison) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+
+	fmt.Fprintln(tw, "algorithm\torigin\tdest\tcost\tgap\texpanded\ttime\t")
+	for _, c := range comparisons {
+		cost, gap := "-", "-"
+		if c.Found {
+			cost = fmt.Sprintf("%.0f", c.Cost)
+			gap = fmt.Sprintf("%.1f%%", c.OptimalityGap*100)
+		}
+		fmt.Fprintf(tw, "%s\t%d,%d\t%d,%d\t%s\t%s\t%d\t%s\t\n",
+			c.Algorithm, c.Origin.X, c.Origin.Y, c.Dest.X, c.Dest.Y, cost, gap, c.Expanded, c.Duration)
+	}
+
+	fmt.Fprintln(tw, "\t\t\t\t\t\t\t")
+	fmt.Fprintln(tw, "algorithm\tfound\ttotal cost\tmean gap\tmax gap\texpanded\ttime\t")
+	for _, algorithm := range Algorithms {
+		found, runs, expanded := 0, 0, 0
+		cost, gap, maxGap := 0.0, 0.0, 0.0
+		var duration time.Duration
+		for _, c := range comparisons {
+			if c.Algorithm != algorithm.Name {
+				continue
+			}
+			runs += 1
+			expanded += c.Expanded
+			duration += c.Duration
+			if c.Found {
+				found += 1
+				cost += c.Cost
+				gap += c.OptimalityGap
+				maxGap = math.Max(maxGap, c.OptimalityGap)
+			}
+		}
+		if runs == 0 {
+			continue
+		}
+		meanGap := 0.0
+		if found > 0 {
+			meanGap = gap / float64(found)
+		}
+		fmt.Fprintf(tw, "%s\t%d/%d\t%.0f\t%.1f%%\t%.1f%%\t%d\t%s\t\n",
+			algorithm.Name, found, runs, cost, meanGap*100, maxGap*100, expanded, duration)
+	}
+
+	return tw.Flush()
+}
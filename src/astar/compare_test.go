@@ -0,0 +1,111 @@
+package astar
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestBidirectionalAStarIsOptimal(t *testing.T) {
+	m, _, _ := parseTestGrid(t, costlyGrid)
+	for _, pair := range RandomPairs(m, 200, rand.New(rand.NewSource(1))) {
+		optimal, path := Dijkstra(m, pair[0], pair[1]), BidirectionalAStar(m, pair[0], pair[1])
+		if (optimal == nil) != (path == nil) {
+			t.Fatalf("from %d,%d to %d,%d: Dijkstra found %v, bidirectional %v", pair[0].X, pair[0].Y, pair[1].X, pair[1].Y, optimal != nil, path != nil)
+		}
+		if path != nil && path.Cost() != optimal.Cost() {
+			t.Fatalf("from %d,%d to %d,%d: bidirectional costs %g, Dijkstra %g", pair[0].X, pair[0].Y, pair[1].X, pair[1].Y, path.Cost(), optimal.Cost())
+		}
+	}
+}
+
+// randomGrid is a w by h map with every cell costing 1 and roughly a third of
+// them walls.
+func randomGrid(w, h int, r *rand.Rand) *GridMap {
+	m := &GridMap{Width: w, Height: h}
+	for y := 0; y < h; y++ {
+		m.Cells = append(m.Cells, []*Cell{})
+		for x := 0; x < w; x++ {
+			m.Cells[y] = append(m.Cells[y], &Cell{X: x, Y: y, Cost: 1, IsWalkable: r.Intn(3) != 0})
+		}
+	}
+	return m
+}
+
+func TestJPSIsOptimal(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 50; i++ {
+		m := randomGrid(12, 9, r)
+		for _, pair := range RandomPairs(m, 20, r) {
+			optimal, path := Dijkstra(m, pair[0], pair[1]), JPS(m, pair[0], pair[1])
+			if (optimal == nil) != (path == nil) {
+				t.Fatalf("from %d,%d to %d,%d: Dijkstra found %v, JPS %v", pair[0].X, pair[0].Y, pair[1].X, pair[1].Y, optimal != nil, path != nil)
+			}
+			if path == nil {
+				continue
+			}
+			if path.Cost() != optimal.Cost() {
+				t.Fatalf("from %d,%d to %d,%d: JPS costs %g, Dijkstra %g", pair[0].X, pair[0].Y, pair[1].X, pair[1].Y, path.Cost(), optimal.Cost())
+			}
+			// every step goes to a walkable neighbor of the one before
+			prev := pair[0]
+			for _, cell := range path.Cells {
+				if !cell.IsWalkable || Heuristic(prev, cell) != 1 {
+					t.Fatalf("JPS steps from %d,%d to %d,%d", prev.X, prev.Y, cell.X, cell.Y)
+				}
+				prev = cell
+			}
+		}
+	}
+}
+
+func TestJPSNeedsUniformCosts(t *testing.T) {
+	m, origin, goal := parseTestGrid(t, costlyGrid)
+	if _, ok := m.UniformCost(); ok {
+		t.Fatal("costly grid has a uniform cost")
+	}
+	if JPS(m, origin, goal) != nil {
+		t.Fatal("JPS searched a map with costs")
+	}
+
+	m, origin, goal = parseTestGrid(t, "S..\n#.#\n..G\n")
+	if cost, ok := m.UniformCost(); !ok || cost != 1 {
+		t.Fatalf("uniform cost %g, %v", cost, ok)
+	}
+	if path := JPS(m, origin, goal); path == nil || path.Cost() != 4 {
+		t.Fatal("JPS found no 4 step path")
+	}
+}
+
+func TestCompare(t *testing.T) {
+	for _, grid := range []string{costlyGrid, "S...\n.##.\n...G\n"} {
+		m, origin, goal := parseTestGrid(t, grid)
+		searching := 0
+		for _, algorithm := range Algorithms {
+			if algorithm.Searches(m) {
+				searching += 1
+			}
+		}
+		comparisons := Compare(m, [][2]*Cell{{origin, goal}})
+		if len(comparisons) != searching {
+			t.Fatalf("%d comparisons for %d algorithms", len(comparisons), searching)
+		}
+		checkComparisons(t, comparisons)
+	}
+}
+
+func checkComparisons(t *testing.T, comparisons []Comparison) {
+	t.Helper()
+	for _, c := range comparisons {
+		if !c.Found {
+			t.Errorf("%s found no path", c.Algorithm)
+			continue
+		}
+		// greedy best-first is the only one that may give up on the best path
+		if c.Algorithm != "Greedy best-first" && c.OptimalityGap != 0 {
+			t.Errorf("%s is %g off the best path", c.Algorithm, c.OptimalityGap)
+		}
+		if c.OptimalityGap < 0 || c.Expanded == 0 {
+			t.Errorf("%s: gap %g after expanding %d nodes", c.Algorithm, c.OptimalityGap, c.Expanded)
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package astar
+
+import (
+	"container/heap"
+	"math"
+)
+
+// FlowField holds the cost of reaching a shared goal from every cell of a
+// GridMap, along with the next cell to step into from each of them. Any
+// number of agents can follow the same field without running their own search.
+// When the goal moves or cells of the map change, the field is repaired from
+// what it held before, re-expanding only the cells whose cost changes.
+type FlowField struct {
+	Map         *GridMap
+	Goal        *Cell
+	Integration [][]float64 // cost of travelling from a cell to the goal
+	Directions  [][]*Cell   // next cell towards the goal, nil if unreachable
+}
+
+func NewFlowField(m *GridMap) *FlowField {
+	f := &FlowField{Map: m}
+	for y := 0; y < m.Height; y++ {
+		f.Integration = append(f.Integration, make([]float64, m.Width))
+		f.Directions = append(f.Directions, make([]*Cell, m.Width))
+	}
+	f.clear()
+	return f
+}
+
+// SetGoal points the field at goal. A goal on the same cell as before keeps
+// the field, so agents can call it every frame. A goal that moves within
+// reach of the old one reuses the old field, see moveGoal; otherwise the
+// field is built again from scratch.
+func (f *FlowField) SetGoal(goal *Cell) {
+	if goal == nil {
+		return
+	}
+	if f.Goal != nil && f.Goal.X == goal.X && f.Goal.Y == goal.Y {
+		return
+	}
+	goal = f.Map.GetGridCell(goal.X, goal.Y)
+	if goal == nil || !f.moveGoal(goal) {
+		f.Goal = goal
+		f.Update()
+	}
+}
+
+// Update rebuilds the whole field for the current goal.
+func (f *FlowField) Update() {
+	f.clear()
+	if f.Goal == nil || !f.Goal.IsWalkable {
+		return
+	}
+
+	var open PriorityQueue
+	f.Integration[f.Goal.Y][f.Goal.X] = 0
+	heap.Push(&open, &Node{Cell: f.Goal})
+	f.expand(&open)
+}
+
+// Repair brings the field up to date after the walkability or cost of cells
+// has changed, e.g. from a GridMap subscriber. Only the cells whose way to the
+// goal ran through a changed cell are cleared; they are expanded again from
+// their neighbors, along with the cells a cheaper changed cell now improves.
+func (f *FlowField) Repair(cells []*Cell) {
+	if f.Goal == nil {
+		return
+	}
+	for _, cell := range cells {
+		if cell.X == f.Goal.X && cell.Y == f.Goal.Y {
+			// the cost of the goal is part of every other cost
+			f.Update()
+			return
+		}
+	}
+
+	// clear every cell whose way to the goal runs through a changed cell
+	cleared := []*Cell{}
+	for _, cell := range cells {
+		if cell = f.Map.GetGridCell(cell.X, cell.Y); cell != nil {
+			f.clearBranch(cell, &cleared)
+		}
+	}
+
+	// the cells next to a cleared one still have their cost, expanding them
+	// again fills the cleared cells back in
+	var open PriorityQueue
+	for _, cell := range cleared {
+		for _, n := range f.Map.Neighbors(cell) {
+			if g := f.Integration[n.Y][n.X]; !math.IsInf(g, 1) {
+				heap.Push(&open, &Node{Cell: n, g: g, f: g})
+			}
+		}
+	}
+	f.expand(&open)
+}
+
+// moveGoal moves the goal to goal without building the field again, and
+// reports whether it could. Every cell keeps its way to the old goal, which
+// then walks back along goal's old path to the new one. That is a way to the
+// new goal, but not always the cheapest: expanding from the cells along the
+// walk back lowers the cost of every cell that has a cheaper one.
+func (f *FlowField) moveGoal(goal *Cell) bool {
+	old := f.Goal
+	if old == nil || !goal.IsWalkable || f.Integration[old.Y][old.X] != 0 ||
+		math.IsInf(f.Integration[goal.Y][goal.X], 1) {
+		return false
+	}
+
+	// the cost of each cell on goal's old path when walking it backwards
+	walk := []*Cell{goal}
+	costs := []float64{0}
+	for cell := goal; cell != old; {
+		next := f.Directions[cell.Y][cell.X]
+		costs = append(costs, costs[len(costs)-1]+cell.Cost)
+		walk = append(walk, next)
+		cell = next
+	}
+
+	offset := costs[len(costs)-1]
+	for y := range f.Integration {
+		for x := range f.Integration[y] {
+			f.Integration[y][x] += offset
+		}
+	}
+
+	var open PriorityQueue
+	for i, cell := range walk {
+		f.Integration[cell.Y][cell.X] = costs[i]
+		f.Directions[cell.Y][cell.X] = nil
+		if i > 0 {
+			f.Directions[cell.Y][cell.X] = walk[i-1]
+		}
+		heap.Push(&open, &Node{Cell: cell, g: costs[i], f: costs[i]})
+	}
+	f.Goal = goal
+	f.expand(&open)
+	return true
+}
+
+// clearBranch clears cell and every cell whose way to the goal runs through
+// it, adding them to cleared.
+func (f *FlowField) clearBranch(cell *Cell, cleared *[]*Cell) {
+	stack := []*Cell{cell}
+	for len(stack) > 0 {
+		cell := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		f.Integration[cell.Y][cell.X] = math.Inf(1)
+		f.Directions[cell.Y][cell.X] = nil
+		*cleared = append(*cleared, cell)
+
+		for _, d := range [][2]int{{-1, 0}, {1, 0}, {0, -1}, {0, 1}} {
+			n := f.Map.GetGridCell(cell.X+d[0], cell.Y+d[1])
+			if n != nil && f.Directions[n.Y][n.X] == cell {
+				stack = append(stack, n)
+			}
+		}
+	}
+}
+
+// expand runs dijkstra outwards from the cells in open, lowering the cost of
+// every cell that can be reached more cheaply through them. Moving from a
+// neighbor into q costs q.Cost.
+func (f *FlowField) expand(open *PriorityQueue) {
+	for open.Len() > 0 {
+		q := heap.Pop(open).(*Node)
+		if q.g > f.Integration[q.Cell.Y][q.Cell.X] {
+			// stale entry, a cheaper one has already been processed
+			continue
+		}
+
+		for _, cell := range f.Map.Neighbors(q.Cell) {
+			g := q.g + q.Cell.Cost
+			if g < f.Integration[cell.Y][cell.X] {
+				f.Integration[cell.Y][cell.X] = g
+				f.Directions[cell.Y][cell.X] = q.Cell
+				heap.Push(open, &Node{Cell: cell, g: g, f: g})
+			}
+		}
+	}
+}
+
+// Next returns the cell to move into from cell, or nil if cell is the goal or
+// the goal cannot be reached from it.
+func (f *FlowField) Next(cell *Cell) *Cell {
+	if cell == nil || f.Map.GetGridCell(cell.X, cell.Y) == nil {
+		return nil
+	}
+	return f.Directions[cell.Y][cell.X]
+}
+
+// Cost returns the cost of travelling from cell to the goal.
+func (f *FlowField) Cost(cell *Cell) float64 {
+	if cell == nil || f.Map.GetGridCell(cell.X, cell.Y) == nil {
+		return math.Inf(1)
+	}
+	return f.Integration[cell.Y][cell.X]
+}
+
+// PathFrom follows the field from originCell to the goal. Like AStar, the
+// returned path excludes the origin cell.
+func (f *FlowField) PathFrom(originCell *Cell) *Path {
+	if math.IsInf(f.Cost(originCell), 1) {
+		return nil
+	}
+
+	path := &Path{}
+	for cell := f.Next(originCell); cell != nil; cell = f.Next(cell) {
+		path.Cells = append(path.Cells, cell)
+	}
+	return path
+}
+
+func (f *FlowField) clear() {
+	for y := range f.Integration {
+		for x := range f.Integration[y] {
+			f.Integration[y][x] = math.Inf(1)
+			f.Directions[y][x] = nil
+		}
+	}
+}
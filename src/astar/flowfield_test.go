@@ -0,0 +1,152 @@
+package astar
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestFlowFieldMatchesAStar(t *testing.T) {
+	m, _, goal := parseTestGrid(t, costlyGrid)
+	f := NewFlowField(m)
+	f.SetGoal(goal)
+
+	for y := range m.Cells {
+		for _, cell := range m.Cells[y] {
+			if !cell.IsWalkable || cell == goal {
+				continue
+			}
+			path := AStar(m, cell, goal)
+			if path == nil {
+				if !math.IsInf(f.Cost(cell), 1) || f.PathFrom(cell) != nil {
+					t.Fatalf("field reaches the goal from %d,%d, A* doesn't", cell.X, cell.Y)
+				}
+				continue
+			}
+			if f.Cost(cell) != path.Cost() {
+				t.Fatalf("field costs %g from %d,%d, A* %g", f.Cost(cell), cell.X, cell.Y, path.Cost())
+			}
+			if followed := f.PathFrom(cell); followed.Cost() != path.Cost() || followed.Cells[len(followed.Cells)-1] != goal {
+				t.Fatalf("following the field from %d,%d costs %g, A* %g", cell.X, cell.Y, followed.Cost(), path.Cost())
+			}
+		}
+	}
+	if f.Cost(goal) != 0 || f.Next(goal) != nil {
+		t.Fatal("the goal isn't the end of the field")
+	}
+}
+
+func TestFlowFieldFollowsGoalAndMap(t *testing.T) {
+	m, origin, goal := parseTestGrid(t, `
+		S...
+		.##.
+		...G
+	`)
+	f := NewFlowField(m)
+	f.SetGoal(goal)
+	if f.Cost(origin) != 5 {
+		t.Fatalf("cost %g, want 5", f.Cost(origin))
+	}
+
+	// a goal given as another cell at the same place doesn't rebuild the field
+	f.Integration[0][0] = 100
+	f.SetGoal(&Cell{X: goal.X, Y: goal.Y})
+	if f.Cost(origin) != 100 {
+		t.Fatal("field rebuilt for the same goal")
+	}
+	f.Integration[0][0] = 5
+
+	f.SetGoal(m.Cells[0][3])
+	if f.Cost(origin) != 3 {
+		t.Fatalf("cost %g after moving the goal, want 3", f.Cost(origin))
+	}
+
+	m.SetCell(1, 0, false, 1)
+	f.Repair([]*Cell{m.Cells[0][1]})
+	if f.Cost(origin) != 7 || f.Next(origin) != m.Cells[1][0] {
+		t.Fatalf("cost %g after walling off the top row, want 7 round the bottom", f.Cost(origin))
+	}
+}
+
+// checkField fails the test unless f holds the same costs as a field built
+// from scratch for its goal, and following it from any cell costs as much.
+func checkField(t *testing.T, f *FlowField) {
+	t.Helper()
+	built := NewFlowField(f.Map)
+	built.Goal = f.Goal
+	built.Update()
+	for y := range f.Map.Cells {
+		for x, cell := range f.Map.Cells[y] {
+			if f.Cost(cell) != built.Cost(cell) {
+				t.Fatalf("cell %d,%d costs %g, %g when built from scratch", x, y, f.Cost(cell), built.Cost(cell))
+			}
+			if path := f.PathFrom(cell); path != nil && path.Cost() != f.Cost(cell) {
+				t.Fatalf("following the field from %d,%d costs %g, not %g", x, y, path.Cost(), f.Cost(cell))
+			}
+		}
+	}
+}
+
+func TestFlowFieldRepairsGoalMovesAndEdits(t *testing.T) {
+	m, _, goal := parseTestGrid(t, costlyGrid)
+	f := NewFlowField(m)
+	f.SetGoal(goal)
+	r := rand.New(rand.NewSource(1))
+
+	walkable := []*Cell{}
+	for y := range m.Cells {
+		for _, cell := range m.Cells[y] {
+			if cell.IsWalkable {
+				walkable = append(walkable, cell)
+			}
+		}
+	}
+	fences := []*Obstacle{}
+	m.Subscribe(f.Repair)
+	for i := 0; i < 300; i++ {
+		switch cell := walkable[r.Intn(len(walkable))]; r.Intn(4) {
+		case 0:
+			// goal moves to another cell, reachable or not
+			f.SetGoal(cell)
+		case 1:
+			fence := &Obstacle{Cells: []*Cell{cell}}
+			m.AddObstacle(fence)
+			fences = append(fences, fence)
+		case 2:
+			if len(fences) > 0 {
+				j := r.Intn(len(fences))
+				m.RemoveObstacle(fences[j])
+				fences = append(fences[:j], fences[j+1:]...)
+			}
+		case 3:
+			m.SetCell(cell.X, cell.Y, true, float64(1+r.Intn(9)))
+		}
+		checkField(t, f)
+	}
+}
+
+func TestFlowFieldGoalStepKeepsUnchangedCells(t *testing.T) {
+	m, _, _ := parseTestGrid(t, `
+		.........
+		.........
+		.........
+	`)
+	built := NewFlowField(m)
+	built.SetGoal(m.Cells[1][5])
+	f := NewFlowField(m)
+	f.SetGoal(m.Cells[1][4])
+
+	// the top left corner can head right or down, pick the one a full build
+	// for the next goal doesn't; stepping the goal right keeps both as cheap,
+	// so the corner keeps the other one unless it is expanded again
+	other := m.Cells[0][1]
+	if built.Directions[0][0] == other {
+		other = m.Cells[1][0]
+	}
+	f.Directions[0][0] = other
+	f.SetGoal(m.Cells[1][5])
+	checkField(t, f)
+	if f.Directions[0][0] != other {
+		t.Fatal("a cell whose way didn't change was expanded again")
+	}
+}
@@ -0,0 +1,81 @@
+package astar
+
+import "math"
+
+// Goal decides which cells end a search and estimates the cost of reaching
+// the nearest of them. Estimate must not overestimate for A* to stay optimal.
+type Goal interface {
+	Reached(cell *Cell) bool
+	Estimate(cell *Cell, heuristic HeuristicFunc) float64
+}
+
+// GoalCells is reached at any of its cells.
+type GoalCells []*Cell
+
+func (g GoalCells) Reached(cell *Cell) bool {
+	for _, goal := range g {
+		if goal.X == cell.X && goal.Y == cell.Y {
+			return true
+		}
+	}
+	return false
+}
+
+func (g GoalCells) Estimate(cell *Cell, heuristic HeuristicFunc) float64 {
+	// the closest goal bounds the cost of reaching any of them
+	estimate := math.Inf(1)
+	for _, goal := range g {
+		estimate = math.Min(estimate, heuristic(cell, goal))
+	}
+	return estimate
+}
+
+// GoalWithin is reached at any cell no more than Radius from Center, as
+// measured by Distance. Use NewGoalWithin to fill in MinCost from the map.
+type GoalWithin struct {
+	Center   *Cell
+	Radius   int
+	Distance HeuristicFunc // nil for Heuristic; must change by at most 1 per step
+	MinCost  float64       // least a step on the map costs, 0 estimates nothing
+}
+
+// NewGoalWithin is reached at any cell no more than radius steps from center
+// on m.
+func NewGoalWithin(m *GridMap, center *Cell, radius int) GoalWithin {
+	return GoalWithin{Center: center, Radius: radius, MinCost: m.MinCost()}
+}
+
+func (g GoalWithin) Reached(cell *Cell) bool {
+	return g.distance(cell) <= float64(g.Radius)
+}
+
+// Estimate ignores the search's heuristic: the region is measured by
+// Distance, and it takes at least one step per unit of Distance to enter it.
+func (g GoalWithin) Estimate(cell *Cell, heuristic HeuristicFunc) float64 {
+	return math.Max(0, g.distance(cell)-float64(g.Radius)) * g.MinCost
+}
+
+func (g GoalWithin) distance(cell *Cell) float64 {
+	if g.Distance == nil {
+		return Heuristic(cell, g.Center)
+	}
+	return g.Distance(cell, g.Center)
+}
+
+// GoalFunc is reached wherever IsGoal returns true. Without an EstimateCost
+// the search has no sense of direction and behaves like Dijkstra.
+type GoalFunc struct {
+	IsGoal       func(cell *Cell) bool
+	EstimateCost func(cell *Cell) float64
+}
+
+func (g GoalFunc) Reached(cell *Cell) bool {
+	return g.IsGoal(cell)
+}
+
+func (g GoalFunc) Estimate(cell *Cell, heuristic HeuristicFunc) float64 {
+	if g.EstimateCost == nil {
+		return 0
+	}
+	return g.EstimateCost(cell)
+}
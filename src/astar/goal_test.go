@@ -0,0 +1,121 @@
+package astar
+
+import (
+	"math"
+	"testing"
+)
+
+func TestGoalCellsReachesCheapest(t *testing.T) {
+	// the goal on the right is closer but costs more to get to
+	m, origin, _ := parseTestGrid(t, `
+		#########
+		#...S.99.
+		#.#######
+		#........
+	`)
+	near, far := m.Cells[1][8], m.Cells[3][8]
+	path, reached := AStarToGoal(m, origin, GoalCells{near, far})
+	if reached != far {
+		t.Fatalf("reached %v, want %d,%d", reached, far.X, far.Y)
+	}
+	if want := AStar(m, origin, far); path.Cost() != want.Cost() || path.Cells[len(path.Cells)-1] != far {
+		t.Fatalf("path costs %g, want %g to the far goal", path.Cost(), want.Cost())
+	}
+}
+
+func TestGoalCellsMatchesBestSingleGoal(t *testing.T) {
+	m, origin, _ := parseTestGrid(t, costlyGrid)
+	goals := GoalCells{m.Cells[7][8], m.Cells[9][1], m.Cells[5][3], m.Cells[9][7]}
+	best := math.Inf(1)
+	for _, goal := range goals {
+		if path := AStar(m, origin, goal); path != nil {
+			best = math.Min(best, path.Cost())
+		}
+	}
+	path, reached := AStarToGoal(m, origin, goals)
+	if path == nil || path.Cost() != best || !goals.Reached(reached) {
+		t.Fatalf("path to %v costs %v, want %g", reached, path, best)
+	}
+}
+
+func TestGoalWithin(t *testing.T) {
+	m, origin, center := parseTestGrid(t, `
+		S.......
+		.###.##.
+		......G.
+	`)
+	goal := NewGoalWithin(m, center, 2)
+	path, reached := AStarToGoal(m, origin, goal)
+	if reached == nil || Heuristic(reached, center) > 2 {
+		t.Fatalf("reached %v, not within 2 of the center", reached)
+	}
+	// the region starts 4 steps along the bottom row
+	if path.Cost() != 6 {
+		t.Fatalf("path costs %g, want 6", path.Cost())
+	}
+	for _, cell := range path.Cells[:len(path.Cells)-1] {
+		if goal.Reached(cell) {
+			t.Fatalf("path goes on past %d,%d, inside the region", cell.X, cell.Y)
+		}
+	}
+	if goal.Estimate(origin, Heuristic) > path.Cost() {
+		t.Fatal("estimate is more than the cost")
+	}
+}
+
+func TestGoalWithinCheapSteps(t *testing.T) {
+	m, origin, center := parseTestGrid(t, `
+		S.........
+		.########.
+		.#......#.
+		.#.####.#.
+		...#G...#.
+	`)
+	for y := range m.Cells {
+		for _, cell := range m.Cells[y] {
+			cell.Cost = 0.25
+		}
+	}
+	if m.MinCost() != 0.25 {
+		t.Fatalf("min cost %g, want 0.25", m.MinCost())
+	}
+
+	for _, distance := range []HeuristicFunc{nil, Euclidean, Chebyshev} {
+		goal := NewGoalWithin(m, center, 1)
+		goal.Distance = distance
+		// the same region searched without an estimate
+		region := GoalFunc{IsGoal: goal.Reached}
+		want, _ := AStarToGoal(m, origin, region)
+
+		path, reached := AStarToGoal(m, origin, goal)
+		if path == nil || !goal.Reached(reached) || path.Cost() != want.Cost() {
+			t.Fatalf("path costs %v, want %g", path, want.Cost())
+		}
+		for y := range m.Cells {
+			for _, cell := range m.Cells[y] {
+				if cheapest, _ := AStarToGoal(m, cell, region); cheapest != nil && goal.Estimate(cell, Heuristic) > cheapest.Cost() {
+					t.Fatalf("estimate %g from %d,%d is more than the cost %g", goal.Estimate(cell, Heuristic), cell.X, cell.Y, cheapest.Cost())
+				}
+			}
+		}
+	}
+}
+
+func TestGoalFunc(t *testing.T) {
+	m, origin, _ := parseTestGrid(t, costlyGrid)
+	// any cell costing 5 or more, here only 4,5
+	goal := GoalFunc{IsGoal: func(cell *Cell) bool { return cell.Cost >= 5 }}
+	path, reached := AStarToGoal(m, origin, goal)
+	if reached != m.Cells[5][4] {
+		t.Fatalf("reached %v, want 4,5", reached)
+	}
+	if want := Dijkstra(m, origin, reached); path.Cost() != want.Cost() {
+		t.Fatalf("path costs %g, want %g", path.Cost(), want.Cost())
+	}
+
+	// the walled off cell at 1,9 can't be reached
+	unreachable := GoalCells{m.Cells[9][1]}
+	if path, reached := AStarToGoal(m, origin, unreachable); path != nil || reached != nil {
+		t.Fatalf("reached %v through %v", reached, path)
+	}
+}
@@ -0,0 +1,77 @@
+package astar
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// TestGoldenPaths runs every algorithm that can search it, and A* with every
+// tie-break, on each grid in testdata and compares the paths with the .golden
+// file next to it.
+// Run with -update to write the golden files again after a deliberate change.
+func TestGoldenPaths(t *testing.T) {
+	grids, err := filepath.Glob(filepath.Join("testdata", "*.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(grids) == 0 {
+		t.Fatal("no grids in testdata")
+	}
+
+	tieBreaks := []struct {
+		name     string
+		tieBreak TieBreak
+	}{{"high g", TieBreakHighG}, {"low h", TieBreakLowH}, {"fifo", TieBreakFIFO}, {"lifo", TieBreakLIFO}}
+
+	for _, grid := range grids {
+		t.Run(filepath.Base(grid), func(t *testing.T) {
+			data, err := os.ReadFile(grid)
+			if err != nil {
+				t.Fatal(err)
+			}
+			m, origin, goal := parseTestGrid(t, string(data))
+
+			var got strings.Builder
+			for _, algorithm := range Algorithms {
+				if !algorithm.Searches(m) {
+					continue
+				}
+				path, _ := algorithm.Search(m, origin, goal, DefaultSearchOptions)
+				writeGoldenPath(&got, algorithm.Name, m, origin, goal, path)
+			}
+			for _, tieBreak := range tieBreaks {
+				options := DefaultSearchOptions
+				options.TieBreak = tieBreak.tieBreak
+				writeGoldenPath(&got, "A* tie-break "+tieBreak.name, m, origin, goal, AStarWithOptions(m, origin, goal, options))
+			}
+
+			golden := strings.TrimSuffix(grid, ".txt") + ".golden"
+			if *update {
+				if err := os.WriteFile(golden, []byte(got.String()), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.String() != string(want) {
+				t.Fatalf("paths differ from %s, got\n%s", golden, got.String())
+			}
+		})
+	}
+}
+
+func writeGoldenPath(s *strings.Builder, name string, m *GridMap, origin, goal *Cell, path *Path) {
+	cost := "no path"
+	if path != nil {
+		cost = fmt.Sprintf("cost %g", path.Cost())
+	}
+	fmt.Fprintf(s, "%s, %s\n%s\n", name, cost, FormatPath(m, origin, goal, path))
+}
@@ -0,0 +1,39 @@
+package astar
+
+import "math"
+
+// NamedHeuristic is a heuristic that can be picked by name, e.g. in the UI.
+type NamedHeuristic struct {
+	Name string
+	Func HeuristicFunc
+}
+
+// Heuristics lists every heuristic a search can use. All of them are
+// admissible on a grid where moves go to the 4 neighbors and cost at least 1,
+// but the ones that estimate lower make the search expand more nodes.
+var Heuristics = []NamedHeuristic{
+	{Name: "Manhattan", Func: Heuristic},
+	{Name: "Euclidean", Func: Euclidean},
+	{Name: "Chebyshev", Func: Chebyshev},
+	{Name: "Zero", Func: Zero},
+}
+
+func Euclidean(cell, destCell *Cell) float64 {
+	return math.Hypot(float64(destCell.X-cell.X), float64(destCell.Y-cell.Y))
+}
+
+func Chebyshev(cell, destCell *Cell) float64 {
+	return math.Max(math.Abs(float64(destCell.X-cell.X)), math.Abs(float64(destCell.Y-cell.Y)))
+}
+
+// Zero turns A* into Dijkstra.
+func Zero(cell, destCell *Cell) float64 {
+	return 0
+}
+
+func (o SearchOptions) heuristic() HeuristicFunc {
+	if o.Heuristic == nil {
+		return Heuristic
+	}
+	return o.Heuristic
+}
@@ -0,0 +1,107 @@
+package astar
+
+import (
+	"math"
+	"sort"
+)
+
+// idaSearch is a depth-first search bounded by f that only remembers the
+// current path, plus a table of the cheapest g seen per cell so that
+// transpositions are not searched again within the same iteration. The table
+// never grows past maxNodes entries.
+type idaSearch struct {
+	m         *GridMap
+	dest      *Cell
+	heuristic HeuristicFunc
+	maxNodes  int
+	path      []*Cell
+	onPath    map[*Cell]bool
+	seen      map[*Cell]float64
+	expanded  int
+	trace     func(Expansion)
+}
+
+// IDAStar finds a path with iterative deepening A*, using at most maxNodes
+// entries in its transposition table. With a maxNodes of 0 only the current
+// path is kept, which is slow on open maps with many equally short routes.
+func IDAStar(m *GridMap, originCell, destCell *Cell, maxNodes int) (path *Path) {
+	path, _ = idaStar(m, originCell, destCell, maxNodes, Heuristic, nil)
+	return path
+}
+
+func idaStar(m *GridMap, originCell, destCell *Cell, maxNodes int, heuristic HeuristicFunc, trace func(Expansion)) (path *Path, expanded int) {
+	if cell := m.GetGridCell(originCell.X, originCell.Y); cell != nil {
+		originCell = cell
+	}
+	if cell := m.GetGridCell(destCell.X, destCell.Y); cell != nil {
+		destCell = cell
+	}
+
+	s := &idaSearch{
+		m:         m,
+		dest:      destCell,
+		heuristic: heuristic,
+		maxNodes:  maxNodes,
+		onPath:    map[*Cell]bool{originCell: true},
+		trace:     trace,
+	}
+
+	// raise the bound to the lowest f that exceeded it until the dest is found
+	bound := heuristic(originCell, destCell)
+	for !math.IsInf(bound, 1) {
+		s.seen = map[*Cell]float64{}
+		next, found := s.search(originCell, 0, bound)
+		if found {
+			path = &Path{Cells: append([]*Cell{}, s.path...)}
+			return path, s.expanded
+		}
+		bound = next
+	}
+	return nil, s.expanded
+}
+
+func (s *idaSearch) search(cell *Cell, g, bound float64) (next float64, found bool) {
+	f := g + s.heuristic(cell, s.dest)
+	if f > bound {
+		return f, false
+	}
+	if cell == s.dest {
+		return f, true
+	}
+
+	// a cheaper visit to this cell has already searched everything below it
+	if prev, ok := s.seen[cell]; ok && prev <= g {
+		return math.Inf(1), false
+	} else if ok || len(s.seen) < s.maxNodes {
+		s.seen[cell] = g
+	}
+	s.expanded += 1
+	if s.trace != nil {
+		s.trace(Expansion{Cell: cell, G: g, H: f - g, F: f})
+	}
+
+	// try the neighbors closest to the dest first
+	neighbors := s.m.Neighbors(cell)
+	sort.SliceStable(neighbors, func(i, j int) bool {
+		return s.heuristic(neighbors[i], s.dest) < s.heuristic(neighbors[j], s.dest)
+	})
+
+	next = math.Inf(1)
+	for _, n := range neighbors {
+		if s.onPath[n] {
+			continue
+		}
+
+		s.path = append(s.path, n)
+		s.onPath[n] = true
+		t, found := s.search(n, g+n.Cost, bound)
+		if found {
+			return t, true
+		}
+		s.path = s.path[:len(s.path)-1]
+		delete(s.onPath, n)
+
+		next = math.Min(next, t)
+	}
+	return next, false
+}
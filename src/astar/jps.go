@@ -0,0 +1,182 @@
+package astar
+
+import (
+	"container/heap"
+	"math"
+)
+
+// jpsSearch is a jump point search over a map whose walkable cells all cost
+// the same. Only moves to the 4 neighbors are allowed, so paths are searched
+// in a canonical order: a horizontal run may turn up or down at any cell,
+// while a vertical run only turns where a wall beside it ends. Every cheapest
+// path can be bent into that order without costing more, so the search only
+// needs to stop at the cells where runs turn, the jump points, instead of
+// every cell in between.
+type jpsSearch struct {
+	m    *GridMap
+	dest *Cell
+}
+
+// JPS finds a cheapest path with jump point search. It returns nil on maps
+// whose walkable cells don't all cost the same, see GridMap.UniformCost.
+func JPS(m *GridMap, originCell, destCell *Cell) (path *Path) {
+	path, _ = jps(m, originCell, destCell, Heuristic, nil)
+	return path
+}
+
+func jpsSearchFunc(m *GridMap, originCell, destCell *Cell, options SearchOptions) (*Path, int) {
+	return jps(m, originCell, destCell, options.heuristic(), options.Trace)
+}
+
+// jps only traces the jump points, the cells in between are never expanded.
+func jps(m *GridMap, originCell, destCell *Cell, heuristic HeuristicFunc, trace func(Expansion)) (path *Path, expanded int) {
+	cost, ok := m.UniformCost()
+	if !ok {
+		return nil, 0
+	}
+	originCell, destCell = m.GetGridCell(originCell.X, originCell.Y), m.GetGridCell(destCell.X, destCell.Y)
+	if originCell == nil || destCell == nil || !originCell.IsWalkable || !destCell.IsWalkable {
+		return nil, 0
+	}
+
+	// the heuristics estimate in cells, so scale them by the cost of one
+	s := &jpsSearch{m: m, dest: destCell}
+	var open PriorityQueue
+	open.TieBreak = TieBreakLowH
+	h := cost * heuristic(originCell, destCell)
+	heap.Push(&open, &Node{Cell: originCell, h: h, f: h})
+	best := map[*Cell]float64{originCell: 0}
+	closed := map[*Cell]bool{}
+
+	for open.Len() > 0 {
+		q := heap.Pop(&open).(*Node)
+		if closed[q.Cell] {
+			continue
+		}
+		closed[q.Cell] = true
+		expanded += 1
+		if trace != nil {
+			trace(Expansion{Cell: q.Cell, G: q.g, H: q.h, F: q.f})
+		}
+		if q.Cell == destCell {
+			return s.path(q), expanded
+		}
+
+		for _, d := range s.directions(q) {
+			jump := s.jump(q.Cell, d[0], d[1])
+			if jump == nil || closed[jump] {
+				continue
+			}
+			g := q.g + cost*Heuristic(q.Cell, jump)
+			if old, ok := best[jump]; ok && old <= g {
+				continue
+			}
+			best[jump] = g
+			h := cost * heuristic(jump, destCell)
+			heap.Push(&open, &Node{Cell: jump, Parent: q, g: g, h: h, f: g + h})
+		}
+	}
+	return nil, expanded
+}
+
+// directions returns the directions to jump in from q: all 4 from the origin,
+// straight on and both sides after a horizontal run, and straight on and the
+// sides a wall opens up after a vertical run.
+func (s *jpsSearch) directions(q *Node) [][2]int {
+	if q.Parent == nil {
+		return [][2]int{{-1, 0}, {1, 0}, {0, -1}, {0, 1}}
+	}
+	dx, dy := sign(q.Cell.X-q.Parent.Cell.X), sign(q.Cell.Y-q.Parent.Cell.Y)
+	if dy == 0 {
+		return [][2]int{{dx, 0}, {0, -1}, {0, 1}}
+	}
+	directions := [][2]int{{0, dy}}
+	for _, side := range []int{-1, 1} {
+		if s.forced(q.Cell.X, q.Cell.Y, side, dy) {
+			directions = append(directions, [2]int{side, 0})
+		}
+	}
+	return directions
+}
+
+// forced reports whether a vertical run moving dy through x, y has to be
+// able to turn towards side there: the cell beside it is open while the one
+// beside the cell it came from is a wall.
+func (s *jpsSearch) forced(x, y, side, dy int) bool {
+	return s.walkable(x+side, y) && !s.walkable(x+side, y-dy)
+}
+
+// jump runs from cell in direction dx, dy and returns the first jump point
+// it reaches, or nil if it runs into a wall or the edge of the map first.
+func (s *jpsSearch) jump(cell *Cell, dx, dy int) *Cell {
+	x, y := cell.X, cell.Y
+	for {
+		x, y = x+dx, y+dy
+		if !s.walkable(x, y) {
+			return nil
+		}
+		next := s.m.Cells[y][x]
+		if next == s.dest {
+			return next
+		}
+		if dy != 0 {
+			if s.forced(x, y, -1, dy) || s.forced(x, y, 1, dy) {
+				return next
+			}
+			continue
+		}
+		// a horizontal run stops where turning up or down leads somewhere
+		if s.jump(next, 0, -1) != nil || s.jump(next, 0, 1) != nil {
+			return next
+		}
+	}
+}
+
+func (s *jpsSearch) walkable(x, y int) bool {
+	cell := s.m.GetGridCell(x, y)
+	return cell != nil && cell.IsWalkable
+}
+
+// path fills in the cells between the jump points leading to n. Like AStar,
+// the path excludes the origin cell.
+func (s *jpsSearch) path(n *Node) *Path {
+	path := &Path{}
+	for ; n.Parent != nil; n = n.Parent {
+		from, to := n.Parent.Cell, n.Cell
+		dx, dy := sign(to.X-from.X), sign(to.Y-from.Y)
+		for x, y := to.X, to.Y; x != from.X || y != from.Y; x, y = x-dx, y-dy {
+			path.Cells = append(path.Cells, s.m.Cells[y][x])
+		}
+	}
+	path.Reverse()
+	return path
+}
+
+// UniformCost returns the cost shared by every walkable cell of m, and false
+// if they don't all cost the same.
+func (m *GridMap) UniformCost() (cost float64, ok bool) {
+	cost = math.NaN()
+	for y := range m.Cells {
+		for _, cell := range m.Cells[y] {
+			if !cell.IsWalkable {
+				continue
+			}
+			if math.IsNaN(cost) {
+				cost = cell.Cost
+			} else if cell.Cost != cost {
+				return 0, false
+			}
+		}
+	}
+	return cost, true
+}
+
+func sign(x int) int {
+	switch {
+	case x < 0:
+		return -1
+	case x > 0:
+		return 1
+	}
+	return 0
+}
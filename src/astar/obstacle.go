@@ -0,0 +1,147 @@
+package astar
+
+// Obstacle is placed on a GridMap at runtime, e.g. a gate or a fence. An
+// obstacle with a Cost of 0 blocks its cells, otherwise crossing them costs
+// that much more.
+type Obstacle struct {
+	Cells []*Cell
+	Cost  float64
+}
+
+// ChangeFunc is called with the cells whose walkability or cost has changed.
+type ChangeFunc func(cells []*Cell)
+
+// cellState is what a cell looked like before any obstacle was put on it.
+type cellState struct {
+	walkable bool
+	cost     float64
+}
+
+// AddObstacle places o on the map and notifies subscribers of the cells that
+// changed. Cells outside the map are ignored.
+func (m *GridMap) AddObstacle(o *Obstacle) {
+	if m.obstacles == nil {
+		m.obstacles = map[*Cell][]*Obstacle{}
+		m.base = map[*Cell]cellState{}
+	}
+
+	changed := []*Cell{}
+	for _, c := range o.Cells {
+		cell := m.GetGridCell(c.X, c.Y)
+		if cell == nil {
+			continue
+		}
+		if _, ok := m.base[cell]; !ok {
+			m.base[cell] = cellState{walkable: cell.IsWalkable, cost: cell.Cost}
+		}
+		m.obstacles[cell] = append(m.obstacles[cell], o)
+		if m.updateCell(cell) {
+			changed = append(changed, cell)
+		}
+	}
+	m.notify(changed)
+}
+
+// RemoveObstacle takes o off the map again and notifies subscribers of the
+// cells that changed. Cells o isn't on are left alone.
+func (m *GridMap) RemoveObstacle(o *Obstacle) {
+	changed := []*Cell{}
+	for _, c := range o.Cells {
+		cell := m.GetGridCell(c.X, c.Y)
+		if cell == nil {
+			continue
+		}
+		obstacles, found := m.obstacles[cell], false
+		for i, other := range obstacles {
+			if other == o {
+				m.obstacles[cell] = append(obstacles[:i], obstacles[i+1:]...)
+				found = true
+				break
+			}
+		}
+		if !found {
+			// without obstacles there is no base state to go back to
+			continue
+		}
+		if m.updateCell(cell) {
+			changed = append(changed, cell)
+		}
+	}
+	m.notify(changed)
+}
+
+// SetCell changes the walkability and cost the cell at x, y has without any
+// obstacles, e.g. when the map is edited, and notifies subscribers if the cell
+// changed.
+func (m *GridMap) SetCell(x, y int, walkable bool, cost float64) {
+	cell := m.GetGridCell(x, y)
+	if cell == nil {
+		return
+	}
+
+	changed := false
+	if _, ok := m.base[cell]; ok {
+		m.base[cell] = cellState{walkable: walkable, cost: cost}
+		changed = m.updateCell(cell)
+	} else if cell.IsWalkable != walkable || cell.Cost != cost {
+		cell.IsWalkable, cell.Cost = walkable, cost
+		changed = true
+	}
+	if changed {
+		m.notify([]*Cell{cell})
+	}
+}
+
+// ObstaclesAt returns the obstacles on the cell at x, y.
+func (m *GridMap) ObstaclesAt(x, y int) []*Obstacle {
+	return m.obstacles[m.GetGridCell(x, y)]
+}
+
+// Subscribe registers fn to be called whenever obstacles change cells.
+func (m *GridMap) Subscribe(fn ChangeFunc) {
+	m.subscribers = append(m.subscribers, fn)
+}
+
+// updateCell works out the walkability and cost of cell from what it was
+// before any obstacles and the obstacles on it now, and reports whether either
+// changed.
+func (m *GridMap) updateCell(cell *Cell) bool {
+	base := m.base[cell]
+	walkable, cost := base.walkable, base.cost
+	for _, o := range m.obstacles[cell] {
+		if o.Cost == 0 {
+			walkable = false
+		} else {
+			cost += o.Cost
+		}
+	}
+	if len(m.obstacles[cell]) == 0 {
+		delete(m.obstacles, cell)
+		delete(m.base, cell)
+	}
+
+	changed := walkable != cell.IsWalkable || cost != cell.Cost
+	cell.IsWalkable = walkable
+	cell.Cost = cost
+	return changed
+}
+
+func (m *GridMap) notify(cells []*Cell) {
+	if len(cells) == 0 {
+		return
+	}
+	for _, fn := range m.subscribers {
+		fn(cells)
+	}
+}
+
+// IsBlocked reports whether any cell left to walk on the path is no longer
+// walkable.
+func (p *Path) IsBlocked() bool {
+	for i := p.CurrentCell; i < len(p.Cells); i++ {
+		if !p.Cells[i].IsWalkable {
+			return true
+		}
+	}
+	return false
+}
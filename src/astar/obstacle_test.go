@@ -0,0 +1,89 @@
+package astar
+
+import "testing"
+
+func TestObstacles(t *testing.T) {
+	m, _, _ := parseTestGrid(t, `
+		....
+		.3..
+	`)
+	var notified [][]*Cell
+	m.Subscribe(func(cells []*Cell) { notified = append(notified, cells) })
+	cell, costly := m.Cells[0][1], m.Cells[1][1]
+
+	fence := &Obstacle{Cells: []*Cell{cell, {X: 9, Y: 9}}}
+	m.AddObstacle(fence)
+	if cell.IsWalkable || len(m.ObstaclesAt(1, 0)) != 1 {
+		t.Fatal("fence doesn't block its cell")
+	}
+	mud := &Obstacle{Cells: []*Cell{cell, costly}, Cost: 2}
+	m.AddObstacle(mud)
+	if cell.IsWalkable || costly.Cost != 5 {
+		t.Fatalf("mud on a fence is walkable %v, mud on a 3 costs %g", cell.IsWalkable, costly.Cost)
+	}
+
+	// taking the fence away leaves the mud under it
+	m.RemoveObstacle(fence)
+	if !cell.IsWalkable || cell.Cost != 3 || len(m.ObstaclesAt(1, 0)) != 1 {
+		t.Fatalf("cell is walkable %v costing %g without the fence", cell.IsWalkable, cell.Cost)
+	}
+	m.RemoveObstacle(mud)
+	if cell.Cost != 1 || costly.Cost != 3 || m.ObstaclesAt(1, 0) != nil {
+		t.Fatalf("cells cost %g and %g without obstacles, want 1 and 3", cell.Cost, costly.Cost)
+	}
+
+	// editing a cell under an obstacle changes what it goes back to
+	m.AddObstacle(fence)
+	m.SetCell(1, 0, true, 7)
+	if cell.IsWalkable {
+		t.Fatal("editing the cell took the fence away")
+	}
+	m.RemoveObstacle(fence)
+	if !cell.IsWalkable || cell.Cost != 7 {
+		t.Fatalf("edited cell costs %g without the fence, want 7", cell.Cost)
+	}
+
+	// every change is notified, even to the cost of a blocked cell, and
+	// nothing outside the map
+	want := [][2]int{{1, 0}, {1, 0}, {1, 1}, {1, 0}, {1, 0}, {1, 1}, {1, 0}, {1, 0}, {1, 0}}
+	got := [][2]int{}
+	for _, cells := range notified {
+		for _, cell := range cells {
+			got = append(got, [2]int{cell.X, cell.Y})
+		}
+	}
+	if len(got) != len(want) {
+		t.Fatalf("notified %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("notified %v, want %v", got, want)
+		}
+	}
+
+	// a change that changes nothing isn't notified, and taking away a fence
+	// that isn't there leaves the cell as it is
+	notified = nil
+	m.SetCell(1, 0, true, 7)
+	m.RemoveObstacle(fence)
+	if len(notified) != 0 || !cell.IsWalkable || cell.Cost != 7 {
+		t.Fatalf("notified %d times without a change, cell walkable %v costing %g", len(notified), cell.IsWalkable, cell.Cost)
+	}
+}
+
+func TestPathIsBlocked(t *testing.T) {
+	m, origin, goal := parseTestGrid(t, "S...G\n")
+	path := AStar(m, origin, goal)
+	if path.IsBlocked() {
+		t.Fatal("open path is blocked")
+	}
+	m.AddObstacle(&Obstacle{Cells: []*Cell{m.Cells[0][1]}})
+	if !path.IsBlocked() {
+		t.Fatal("fenced path isn't blocked")
+	}
+	// cells already walked don't count
+	path.Next()
+	if path.IsBlocked() {
+		t.Fatal("path is blocked behind the walker")
+	}
+}
@@ -0,0 +1,36 @@
+package astar
+
+import "container/heap"
+
+// ReachableWithin returns every cell that can be reached from originCell for a
+// total cost of at most maxCost, including the origin itself.
+func ReachableWithin(m *GridMap, originCell *Cell, maxCost float64) []*Cell {
+	originCell = m.GetGridCell(originCell.X, originCell.Y)
+	if originCell == nil {
+		return nil
+	}
+
+	costs := map[*Cell]float64{originCell: 0}
+	reachable := []*Cell{}
+	var open PriorityQueue
+	heap.Push(&open, &Node{Cell: originCell})
+
+	for open.Len() > 0 {
+		q := heap.Pop(&open).(*Node)
+		if q.g > costs[q.Cell] {
+			// stale entry, a cheaper one has already been processed
+			continue
+		}
+		reachable = append(reachable, q.Cell)
+
+		for _, cell := range m.Neighbors(q.Cell) {
+			g := q.g + cell.Cost
+			if prev, ok := costs[cell]; g > maxCost || (ok && g >= prev) {
+				continue
+			}
+			costs[cell] = g
+			heap.Push(&open, &Node{Cell: cell, g: g, f: g})
+		}
+	}
+	return reachable
+}
@@ -0,0 +1,79 @@
+package astar
+
+import (
+	"a-star/src/utils"
+	"math/rand"
+	"testing"
+)
+
+// openGrid has room for many equally short routes, which IDA* finds hard.
+const openGrid = `
+	............
+	..3....#....
+	..3.##.#..2.
+	.......#..2.
+	.9999.......
+	......##.##.
+	.2..........
+	.2..#...5...
+`
+
+// findAlgorithm returns the algorithm called name, failing the test without one.
+func findAlgorithm(t *testing.T, name string) Algorithm {
+	t.Helper()
+	for _, algorithm := range Algorithms {
+		if algorithm.Name == name {
+			return algorithm
+		}
+	}
+	t.Fatalf("no algorithm %s", name)
+	return Algorithm{}
+}
+
+func TestSearchesMatchAStarCost(t *testing.T) {
+	searches := []struct {
+		name   string
+		search func(m *GridMap, originCell, destCell *Cell) *Path
+	}{
+		{"IDA*", func(m *GridMap, originCell, destCell *Cell) *Path {
+			return IDAStar(m, originCell, destCell, utils.SearchNodeLimit)
+		}},
+		{"SMA*", func(m *GridMap, originCell, destCell *Cell) *Path {
+			return SMAStar(m, originCell, destCell, utils.SearchNodeLimit)
+		}},
+		{"bidirectional A*", BidirectionalAStar},
+	}
+
+	for _, grid := range []string{costlyGrid, openGrid} {
+		m, _, _ := parseTestGrid(t, grid)
+		pairs := RandomPairs(m, 50, rand.New(rand.NewSource(1)))
+		for _, search := range searches {
+			t.Run(search.name, func(t *testing.T) {
+				for _, pair := range pairs {
+					want, path := AStar(m, pair[0], pair[1]), search.search(m, pair[0], pair[1])
+					if (want == nil) != (path == nil) {
+						t.Fatalf("from %d,%d to %d,%d: A* found %v, %s %v", pair[0].X, pair[0].Y, pair[1].X, pair[1].Y, want != nil, search.name, path != nil)
+					}
+					if path != nil && path.Cost() != want.Cost() {
+						t.Fatalf("from %d,%d to %d,%d: %s costs %g, A* %g", pair[0].X, pair[0].Y, pair[1].X, pair[1].Y, search.name, path.Cost(), want.Cost())
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestNodeLimitOption(t *testing.T) {
+	m, origin, goal := parseTestGrid(t, "S.........G\n")
+	sma := findAlgorithm(t, "SMA*")
+
+	options := DefaultSearchOptions
+	if path, _ := sma.Search(m, origin, goal, options); path == nil || path.Cost() != 10 {
+		t.Fatalf("SMA* found %v within the default limit", path)
+	}
+	// the path is 10 cells long, too many for 4 nodes
+	options.NodeLimit = 4
+	if path, _ := sma.Search(m, origin, goal, options); path != nil {
+		t.Fatalf("SMA* found %d cells within 4 nodes", len(path.Cells))
+	}
+}
@@ -0,0 +1,305 @@
+package astar
+
+import (
+	"math"
+	"sort"
+)
+
+type smaNode struct {
+	cell      *Cell
+	parent    *smaNode
+	children  []*smaNode
+	forgotten map[*Cell]float64 // lowest f of children dropped to free memory
+	g         float64
+	f         float64
+	depth     int
+}
+
+// smaSearch is simplified memory-bounded A*. It behaves like A* until
+// maxNodes nodes are in memory, then drops the worst leaf to make room and
+// remembers its f in the parent so the subtree can be regenerated later.
+type smaSearch struct {
+	m         *GridMap
+	dest      *Cell
+	heuristic HeuristicFunc
+	maxNodes  int
+	open      []*smaNode
+	memory    []*smaNode
+	cells     map[*Cell][]*smaNode // nodes in memory for each cell
+	expanded  int
+}
+
+// SMAStar finds a path while keeping at most maxNodes search nodes in memory.
+// The path is optimal as long as maxNodes exceeds the length of the optimal
+// path, otherwise nil may be returned even if a path exists.
+func SMAStar(m *GridMap, originCell, destCell *Cell, maxNodes int) (path *Path) {
+	path, _ = smaStar(m, originCell, destCell, maxNodes, Heuristic, nil)
+	return path
+}
+
+// smaStar traces a node each time it generates a successor, with its f backed
+// up from the children it has generated so far.
+func smaStar(m *GridMap, originCell, destCell *Cell, maxNodes int, heuristic HeuristicFunc, trace func(Expansion)) (path *Path, expanded int) {
+	if cell := m.GetGridCell(originCell.X, originCell.Y); cell != nil {
+		originCell = cell
+	}
+	if cell := m.GetGridCell(destCell.X, destCell.Y); cell != nil {
+		destCell = cell
+	}
+
+	s := &smaSearch{
+		m:         m,
+		dest:      destCell,
+		heuristic: heuristic,
+		maxNodes:  maxNodes,
+		cells:     map[*Cell][]*smaNode{},
+	}
+	root := &smaNode{cell: originCell, f: heuristic(originCell, destCell)}
+	s.add(root)
+
+	for {
+		s.removeExhausted()
+		b, f := s.best()
+		if b == nil || math.IsInf(f, 1) {
+			return nil, s.expanded
+		}
+
+		// if destination has been reached, reconstruct path and return
+		if b.cell == destCell {
+			path = &Path{}
+			for n := b; n.parent != nil; n = n.parent {
+				path.Cells = append(path.Cells, n.cell)
+			}
+			path.Reverse()
+			return path, s.expanded
+		}
+
+		cell, forgottenF, wasForgotten := s.nextSuccessor(b)
+		s.expanded += 1
+		if trace != nil {
+			trace(Expansion{Cell: b.cell, G: b.g, H: b.f - b.g, F: b.f})
+		}
+
+		child := &smaNode{
+			cell:   cell,
+			parent: b,
+			g:      b.g + cell.Cost,
+			depth:  b.depth + 1,
+		}
+		if wasForgotten {
+			child.f = math.Max(b.f, forgottenF)
+			delete(b.forgotten, cell)
+		} else {
+			child.f = math.Max(b.f, child.g+heuristic(cell, destCell))
+		}
+		if cell != destCell && child.depth >= maxNodes-1 {
+			// there is no memory left to go any deeper along this path
+			child.f = math.Inf(1)
+		}
+		b.children = append(b.children, child)
+		s.add(child)
+
+		if next, _, _ := s.nextSuccessor(b); next == nil {
+			s.removeOpen(b)
+		}
+		s.backup(b)
+
+		for len(s.memory) > maxNodes {
+			if !s.forget(child) {
+				break
+			}
+		}
+	}
+}
+
+// removeExhausted takes nodes with nothing left to generate out of the open
+// list. Those without children are dead ends and can never reach the dest.
+func (s *smaSearch) removeExhausted() {
+	for _, n := range append([]*smaNode{}, s.open...) {
+		if n.cell == s.dest {
+			continue
+		}
+		if cell, _, _ := s.nextSuccessor(n); cell != nil {
+			continue
+		}
+		s.removeOpen(n)
+		if len(n.children) == 0 {
+			n.f = math.Inf(1)
+			s.backup(n.parent)
+		}
+	}
+}
+
+// best returns the open node that would generate the successor with the
+// lowest f, preferring deeper nodes, along with that f.
+func (s *smaSearch) best() (best *smaNode, bestF float64) {
+	for _, n := range s.open {
+		f := n.f
+		if n.cell != s.dest && len(s.unseenSuccessors(n)) == 0 {
+			// only forgotten children are left to regenerate
+			f = math.Inf(1)
+			for _, childF := range n.forgotten {
+				f = math.Min(f, math.Max(n.f, childF))
+			}
+		}
+		if best == nil || f < bestF || (f == bestF && n.depth > best.depth) {
+			best, bestF = n, f
+		}
+	}
+	return best, bestF
+}
+
+// unseenSuccessors returns the neighbors of n that have never been generated
+// from n, skipping cells already on the path to n and cells that another node
+// in memory reaches at least as cheaply.
+func (s *smaSearch) unseenSuccessors(n *smaNode) []*Cell {
+	cells := []*Cell{}
+	for _, cell := range s.m.Neighbors(n.cell) {
+		if _, ok := n.forgotten[cell]; ok || s.isChild(n, cell) || s.onPath(n, cell) {
+			continue
+		}
+		dominated := false
+		for _, other := range s.cells[cell] {
+			if other.g <= n.g+cell.Cost {
+				dominated = true
+				break
+			}
+		}
+		if !dominated {
+			cells = append(cells, cell)
+		}
+	}
+	sort.SliceStable(cells, func(i, j int) bool {
+		return s.heuristic(cells[i], s.dest) < s.heuristic(cells[j], s.dest)
+	})
+	return cells
+}
+
+// nextSuccessor picks the next cell to generate from n: an unseen neighbor if
+// there is one, otherwise the forgotten child with the lowest f.
+func (s *smaSearch) nextSuccessor(n *smaNode) (cell *Cell, forgottenF float64, wasForgotten bool) {
+	if unseen := s.unseenSuccessors(n); len(unseen) > 0 {
+		return unseen[0], 0, false
+	}
+	for c, f := range n.forgotten {
+		if cell == nil || f < forgottenF || (f == forgottenF && (c.Y < cell.Y || (c.Y == cell.Y && c.X < cell.X))) {
+			cell, forgottenF = c, f
+		}
+	}
+	return cell, forgottenF, cell != nil
+}
+
+// backup raises the f of n to the lowest f among its children, including
+// forgotten ones, and passes the change on to its ancestors. Nodes that still
+// have successors to generate for the first time are left as they are.
+func (s *smaSearch) backup(n *smaNode) {
+	for ; n != nil; n = n.parent {
+		if len(s.unseenSuccessors(n)) > 0 {
+			return
+		}
+		f := math.Inf(1)
+		for _, child := range n.children {
+			f = math.Min(f, child.f)
+		}
+		for _, childF := range n.forgotten {
+			f = math.Min(f, childF)
+		}
+		if f <= n.f {
+			return
+		}
+		n.f = f
+	}
+}
+
+// forget drops the shallowest leaf with the highest f, other than keep, from
+// memory and reports whether one could be dropped.
+func (s *smaSearch) forget(keep *smaNode) bool {
+	var worst *smaNode
+	for _, n := range s.memory {
+		if n.parent == nil || n == keep || len(n.children) > 0 {
+			continue
+		}
+		if worst == nil || n.f > worst.f || (n.f == worst.f && n.depth < worst.depth) {
+			worst = n
+		}
+	}
+	if worst == nil {
+		return false
+	}
+
+	parent := worst.parent
+	for i, child := range parent.children {
+		if child == worst {
+			parent.children = append(parent.children[:i], parent.children[i+1:]...)
+			break
+		}
+	}
+	if parent.forgotten == nil {
+		parent.forgotten = map[*Cell]float64{}
+	}
+	if f, ok := parent.forgotten[worst.cell]; !ok || worst.f < f {
+		parent.forgotten[worst.cell] = worst.f
+	}
+
+	s.remove(worst)
+	if !s.isOpen(parent) {
+		s.open = append(s.open, parent)
+	}
+	s.backup(parent)
+	return true
+}
+
+func (s *smaSearch) add(n *smaNode) {
+	s.open = append(s.open, n)
+	s.memory = append(s.memory, n)
+	s.cells[n.cell] = append(s.cells[n.cell], n)
+}
+
+func (s *smaSearch) remove(n *smaNode) {
+	s.removeOpen(n)
+	s.memory = removeSMANode(s.memory, n)
+	s.cells[n.cell] = removeSMANode(s.cells[n.cell], n)
+	if len(s.cells[n.cell]) == 0 {
+		delete(s.cells, n.cell)
+	}
+}
+
+func (s *smaSearch) removeOpen(n *smaNode) {
+	s.open = removeSMANode(s.open, n)
+}
+
+func (s *smaSearch) isOpen(n *smaNode) bool {
+	for _, o := range s.open {
+		if o == n {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *smaSearch) isChild(n *smaNode, cell *Cell) bool {
+	for _, child := range n.children {
+		if child.cell == cell {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *smaSearch) onPath(n *smaNode, cell *Cell) bool {
+	for ; n != nil; n = n.parent {
+		if n.cell == cell {
+			return true
+		}
+	}
+	return false
+}
+
+func removeSMANode(nodes []*smaNode, n *smaNode) []*smaNode {
+	for i, o := range nodes {
+		if o == n {
+			return append(nodes[:i], nodes[i+1:]...)
+		}
+	}
+	return nodes
+}
@@ -0,0 +1,308 @@
+package bt
+
+type Status int
+
+const (
+	Success Status = iota
+	Failure
+	Running
+)
+
+// Node is a behaviour tree node. Tick runs the node for one frame and Reset
+// returns it to its initial state so it can be run again from the start.
+type Node interface {
+	Tick() Status
+	Reset()
+}
+
+// Sequence runs its children in order until one fails. A running child is
+// resumed on the next tick.
+type Sequence struct {
+	Children []Node
+	current  int
+}
+
+func (n *Sequence) Tick() Status {
+	for n.current < len(n.Children) {
+		status := n.Children[n.current].Tick()
+		if status == Running {
+			return Running
+		}
+		if status == Failure {
+			n.Reset()
+			return Failure
+		}
+		n.current += 1
+	}
+	n.Reset()
+	return Success
+}
+
+func (n *Sequence) Reset() {
+	n.current = 0
+	resetAll(n.Children)
+}
+
+// Selector runs its children in order until one succeeds. A running child is
+// resumed on the next tick.
+type Selector struct {
+	Children []Node
+	current  int
+}
+
+func (n *Selector) Tick() Status {
+	for n.current < len(n.Children) {
+		status := n.Children[n.current].Tick()
+		if status == Running {
+			return Running
+		}
+		if status == Success {
+			n.Reset()
+			return Success
+		}
+		n.current += 1
+	}
+	n.Reset()
+	return Failure
+}
+
+func (n *Selector) Reset() {
+	n.current = 0
+	resetAll(n.Children)
+}
+
+// ReactiveSequence checks every child from the first on each tick, so an
+// earlier condition that stops holding interrupts a running child.
+type ReactiveSequence struct {
+	Children []Node
+	running  Node
+}
+
+func (n *ReactiveSequence) Tick() Status {
+	for _, child := range n.Children {
+		status := child.Tick()
+		if status == Success {
+			continue
+		}
+		n.running = interrupt(n.running, child, status)
+		return status
+	}
+	n.Reset()
+	return Success
+}
+
+func (n *ReactiveSequence) Reset() {
+	n.running = nil
+	resetAll(n.Children)
+}
+
+// ReactiveSelector checks every child from the first on each tick, so a
+// higher priority child that succeeds or starts running interrupts a lower
+// priority one.
+type ReactiveSelector struct {
+	Children []Node
+	running  Node
+}
+
+func (n *ReactiveSelector) Tick() Status {
+	for _, child := range n.Children {
+		status := child.Tick()
+		if status == Failure {
+			continue
+		}
+		n.running = interrupt(n.running, child, status)
+		return status
+	}
+	n.Reset()
+	return Failure
+}
+
+func (n *ReactiveSelector) Reset() {
+	n.running = nil
+	resetAll(n.Children)
+}
+
+// interrupt resets the child that was running if a different child decided
+// the outcome this tick, and returns the child that is running now.
+func interrupt(running, child Node, status Status) Node {
+	if running != nil && running != child {
+		running.Reset()
+	}
+	if status == Running {
+		return child
+	}
+	return nil
+}
+
+// Parallel ticks all of its children every tick. It succeeds once
+// SuccessThreshold children have succeeded and fails once FailureThreshold
+// children have failed. A threshold of 0 means all children.
+type Parallel struct {
+	Children         []Node
+	SuccessThreshold int
+	FailureThreshold int
+	done             []Status
+}
+
+func (n *Parallel) Tick() Status {
+	if len(n.done) != len(n.Children) {
+		n.done = make([]Status, len(n.Children))
+		for i := range n.done {
+			n.done[i] = Running
+		}
+	}
+
+	succeeded, failed := 0, 0
+	for i, child := range n.Children {
+		if n.done[i] == Running {
+			n.done[i] = child.Tick()
+		}
+		switch n.done[i] {
+		case Success:
+			succeeded += 1
+		case Failure:
+			failed += 1
+		}
+	}
+
+	if succeeded >= threshold(n.SuccessThreshold, len(n.Children)) {
+		n.Reset()
+		return Success
+	}
+	if failed >= threshold(n.FailureThreshold, len(n.Children)) {
+		n.Reset()
+		return Failure
+	}
+	return Running
+}
+
+func (n *Parallel) Reset() {
+	n.done = nil
+	resetAll(n.Children)
+}
+
+// Inverter turns the success of its child into failure and vice versa.
+type Inverter struct {
+	Child Node
+}
+
+func (n *Inverter) Tick() Status {
+	switch n.Child.Tick() {
+	case Success:
+		return Failure
+	case Failure:
+		return Success
+	}
+	return Running
+}
+
+func (n *Inverter) Reset() { n.Child.Reset() }
+
+// Succeeder always succeeds once its child has finished.
+type Succeeder struct {
+	Child Node
+}
+
+func (n *Succeeder) Tick() Status {
+	if n.Child.Tick() == Running {
+		return Running
+	}
+	return Success
+}
+
+func (n *Succeeder) Reset() { n.Child.Reset() }
+
+// Repeater runs its child Times times, or forever if Times is 0. It fails as
+// soon as the child fails.
+type Repeater struct {
+	Child Node
+	Times int
+	count int
+}
+
+func (n *Repeater) Tick() Status {
+	status := n.Child.Tick()
+	switch status {
+	case Running:
+		return Running
+	case Failure:
+		n.Reset()
+		return Failure
+	}
+
+	n.Child.Reset()
+	n.count += 1
+	if n.Times > 0 && n.count >= n.Times {
+		n.Reset()
+		return Success
+	}
+	return Running
+}
+
+func (n *Repeater) Reset() {
+	n.count = 0
+	n.Child.Reset()
+}
+
+// UntilFail runs its child again and again until it fails, then succeeds.
+type UntilFail struct {
+	Child Node
+}
+
+func (n *UntilFail) Tick() Status {
+	switch n.Child.Tick() {
+	case Failure:
+		n.Child.Reset()
+		return Success
+	case Success:
+		n.Child.Reset()
+	}
+	return Running
+}
+
+func (n *UntilFail) Reset() { n.Child.Reset() }
+
+// Action is a leaf that calls Run on every tick and OnReset when reset. An
+// action that remembers something between ticks can give it to Save, and take
+// it back in Restore, so it is kept along with the tree's state.
+type Action struct {
+	Run     func() Status
+	OnReset func()
+	Save    func() []int
+	Restore func(values []int)
+}
+
+func (n *Action) Tick() Status { return n.Run() }
+
+func (n *Action) Reset() {
+	if n.OnReset != nil {
+		n.OnReset()
+	}
+}
+
+// Condition is a leaf that succeeds when Check returns true.
+type Condition struct {
+	Check func() bool
+}
+
+func (n *Condition) Tick() Status {
+	if n.Check() {
+		return Success
+	}
+	return Failure
+}
+
+func (n *Condition) Reset() {}
+
+func resetAll(nodes []Node) {
+	for _, n := range nodes {
+		n.Reset()
+	}
+}
+
+func threshold(t, children int) int {
+	if t <= 0 || t > children {
+		return children
+	}
+	return t
+}
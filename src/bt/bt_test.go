@@ -0,0 +1,360 @@
+package bt
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// scripted is a leaf returning its statuses in turn, repeating the last one.
+type scripted struct {
+	statuses []Status
+	ticks    int
+	resets   int
+}
+
+func (n *scripted) Tick() Status {
+	status := n.statuses[min(n.ticks, len(n.statuses)-1)]
+	n.ticks += 1
+	return status
+}
+
+func (n *scripted) Reset() { n.resets += 1 }
+
+const (
+	S = Success
+	F = Failure
+	R = Running
+)
+
+func TestNodes(t *testing.T) {
+	tests := []struct {
+		name       string
+		leaves     [][]Status
+		build      func(children []Node) Node
+		want       []Status // returned by each tick in turn
+		wantTicks  []int    // times each leaf was ticked
+		wantResets []int    // times each leaf was reset, not checked if nil
+	}{
+		{
+			name:      "sequence succeeds when all children do",
+			leaves:    [][]Status{{S}, {S}},
+			build:     func(c []Node) Node { return &Sequence{Children: c} },
+			want:      []Status{S},
+			wantTicks: []int{1, 1},
+		},
+		{
+			name:      "sequence stops at the first failure",
+			leaves:    [][]Status{{F}, {S}},
+			build:     func(c []Node) Node { return &Sequence{Children: c} },
+			want:      []Status{F},
+			wantTicks: []int{1, 0},
+		},
+		{
+			name:      "sequence resumes the running child",
+			leaves:    [][]Status{{S}, {R, S}},
+			build:     func(c []Node) Node { return &Sequence{Children: c} },
+			want:      []Status{R, S},
+			wantTicks: []int{1, 2},
+		},
+		{
+			name:      "selector stops at the first success",
+			leaves:    [][]Status{{F}, {S}, {S}},
+			build:     func(c []Node) Node { return &Selector{Children: c} },
+			want:      []Status{S},
+			wantTicks: []int{1, 1, 0},
+		},
+		{
+			name:      "selector fails when all children do",
+			leaves:    [][]Status{{F}, {F}},
+			build:     func(c []Node) Node { return &Selector{Children: c} },
+			want:      []Status{F},
+			wantTicks: []int{1, 1},
+		},
+		{
+			name:      "selector resumes the running child",
+			leaves:    [][]Status{{F}, {R, S}},
+			build:     func(c []Node) Node { return &Selector{Children: c} },
+			want:      []Status{R, S},
+			wantTicks: []int{1, 2},
+		},
+		{
+			name:       "reactive sequence checks earlier children every tick",
+			leaves:     [][]Status{{S}, {R}},
+			build:      func(c []Node) Node { return &ReactiveSequence{Children: c} },
+			want:       []Status{R, R},
+			wantTicks:  []int{2, 2},
+			wantResets: []int{0, 0},
+		},
+		{
+			name:       "reactive sequence interrupts the running child",
+			leaves:     [][]Status{{S, F}, {R}},
+			build:      func(c []Node) Node { return &ReactiveSequence{Children: c} },
+			want:       []Status{R, F},
+			wantTicks:  []int{2, 1},
+			wantResets: []int{0, 1},
+		},
+		{
+			name:       "reactive selector lets a higher priority child interrupt",
+			leaves:     [][]Status{{F, S}, {R}},
+			build:      func(c []Node) Node { return &ReactiveSelector{Children: c} },
+			want:       []Status{R, S},
+			wantTicks:  []int{2, 1},
+			wantResets: []int{0, 1},
+		},
+		{
+			name:      "reactive selector fails when all children do",
+			leaves:    [][]Status{{F}, {F}},
+			build:     func(c []Node) Node { return &ReactiveSelector{Children: c} },
+			want:      []Status{F},
+			wantTicks: []int{1, 1},
+		},
+		{
+			name:      "parallel waits for all children by default",
+			leaves:    [][]Status{{S}, {R, S}},
+			build:     func(c []Node) Node { return &Parallel{Children: c, FailureThreshold: 1} },
+			want:      []Status{R, S},
+			wantTicks: []int{1, 2},
+		},
+		{
+			name:      "parallel fails at the failure threshold",
+			leaves:    [][]Status{{R}, {F}},
+			build:     func(c []Node) Node { return &Parallel{Children: c, FailureThreshold: 1} },
+			want:      []Status{F},
+			wantTicks: []int{1, 1},
+		},
+		{
+			name:      "parallel succeeds at the success threshold",
+			leaves:    [][]Status{{R}, {R, S}},
+			build:     func(c []Node) Node { return &Parallel{Children: c, SuccessThreshold: 1} },
+			want:      []Status{R, S},
+			wantTicks: []int{2, 2},
+		},
+		{
+			name:   "inverter swaps success and failure",
+			leaves: [][]Status{{S, R, F}},
+			build:  func(c []Node) Node { return &Inverter{Child: c[0]} },
+			want:   []Status{F, R, S},
+		},
+		{
+			name:   "succeeder succeeds once the child has finished",
+			leaves: [][]Status{{R, F}},
+			build:  func(c []Node) Node { return &Succeeder{Child: c[0]} },
+			want:   []Status{R, S},
+		},
+		{
+			name:       "repeater runs the child a number of times",
+			leaves:     [][]Status{{S}},
+			build:      func(c []Node) Node { return &Repeater{Child: c[0], Times: 3} },
+			want:       []Status{R, R, S},
+			wantTicks:  []int{3},
+			wantResets: []int{4},
+		},
+		{
+			name:   "repeater fails when the child fails",
+			leaves: [][]Status{{S, F}},
+			build:  func(c []Node) Node { return &Repeater{Child: c[0]} },
+			want:   []Status{R, F},
+		},
+		{
+			name:   "until fail succeeds once the child fails",
+			leaves: [][]Status{{S, R, S, F}},
+			build:  func(c []Node) Node { return &UntilFail{Child: c[0]} },
+			want:   []Status{R, R, R, S},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			leaves := []*scripted{}
+			children := []Node{}
+			for _, statuses := range test.leaves {
+				leaf := &scripted{statuses: statuses}
+				leaves = append(leaves, leaf)
+				children = append(children, leaf)
+			}
+			node := test.build(children)
+
+			got := []Status{}
+			for range test.want {
+				got = append(got, node.Tick())
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+			for i, leaf := range leaves {
+				if test.wantTicks != nil && leaf.ticks != test.wantTicks[i] {
+					t.Errorf("leaf %d ticked %d times, want %d", i, leaf.ticks, test.wantTicks[i])
+				}
+				if test.wantResets != nil && leaf.resets != test.wantResets[i] {
+					t.Errorf("leaf %d reset %d times, want %d", i, leaf.resets, test.wantResets[i])
+				}
+			}
+		})
+	}
+}
+
+// testLeaves returns leaves that always succeed, recording the type of each
+// leaf built.
+func testLeaves(built *[]string) Leaves {
+	leaf := func(name string) Leaf {
+		return func(params Params) (Node, error) {
+			*built = append(*built, name)
+			return &scripted{statuses: []Status{S}}, nil
+		}
+	}
+	return Leaves{"canSee": leaf("canSee"), "moveTo": leaf("moveTo"), "flee": leaf("flee"), "wait": leaf("wait")}
+}
+
+func TestBuildCurious(t *testing.T) {
+	file, err := os.Open("../../assets/behaviours/curious.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	built := []string{}
+	tree, err := Load(file, testLeaves(&built))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	root, ok := tree.(*ReactiveSelector)
+	if !ok {
+		t.Fatalf("root is %T, want a reactive selector", tree)
+	}
+	if len(root.Children) != 3 {
+		t.Fatalf("root has %d children, want 3", len(root.Children))
+	}
+	for i, want := range []string{"*bt.ReactiveSequence", "*bt.ReactiveSequence", "*bt.Sequence"} {
+		if got := reflect.TypeOf(root.Children[i]).String(); got != want {
+			t.Errorf("child %d is %s, want %s", i, got, want)
+		}
+	}
+	wantBuilt := []string{"canSee", "flee", "canSee", "moveTo", "moveTo", "wait", "moveTo", "wait"}
+	if !reflect.DeepEqual(built, wantBuilt) {
+		t.Errorf("built leaves %v, want %v", built, wantBuilt)
+	}
+}
+
+func TestBuildParams(t *testing.T) {
+	def := `{"type": "parallel", "params": {"success": 1, "failure": 2}, "children": [
+		{"type": "repeater", "params": {"times": 3}, "children": [{"type": "wait"}]},
+		{"type": "wait"}
+	]}`
+	tree, err := Load(strings.NewReader(def), testLeaves(&[]string{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parallel := tree.(*Parallel)
+	if parallel.SuccessThreshold != 1 || parallel.FailureThreshold != 2 {
+		t.Errorf("thresholds %d, %d, want 1, 2", parallel.SuccessThreshold, parallel.FailureThreshold)
+	}
+	if repeater := parallel.Children[0].(*Repeater); repeater.Times != 3 {
+		t.Errorf("repeater times %d, want 3", repeater.Times)
+	}
+}
+
+func TestBuildErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		def  string
+		want string
+	}{
+		{"unknown type", `{"type": "dance"}`, `unknown node type "dance"`},
+		{"decorator without a child", `{"type": "inverter"}`, "inverter needs exactly one child, got 0"},
+		{"decorator with two children", `{"type": "succeeder", "children": [{"type": "wait"}, {"type": "wait"}]}`,
+			"succeeder needs exactly one child, got 2"},
+		{"leaf with children", `{"type": "wait", "children": [{"type": "wait"}]}`, "leaf wait cannot have children"},
+		{"error in a child", `{"type": "sequence", "children": [{"type": "dance"}]}`, `unknown node type "dance"`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := Load(strings.NewReader(test.def), testLeaves(&[]string{}))
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Errorf("got error %v, want %q", err, test.want)
+			}
+		})
+	}
+}
+
+// counter is an action that succeeds every n ticks and saves its count.
+func counter(n int) *Action {
+	count := 0
+	return &Action{
+		Run: func() Status {
+			count += 1
+			if count%n == 0 {
+				return Success
+			}
+			return Running
+		},
+		OnReset: func() { count = 0 },
+		Save:    func() []int { return []int{count} },
+		Restore: func(values []int) { count = values[0] },
+	}
+}
+
+func stateTree() Node {
+	return &ReactiveSelector{Children: []Node{
+		&Inverter{Child: &Condition{Check: func() bool { return true }}},
+		&Sequence{Children: []Node{
+			counter(3),
+			&Repeater{Child: counter(2), Times: 3},
+			&Parallel{Children: []Node{counter(4), counter(5)}},
+		}},
+	}}
+}
+
+func TestSaveAndRestoreState(t *testing.T) {
+	tree := stateTree()
+	for i := 0; i < 7; i++ {
+		tree.Tick()
+	}
+	saved := SaveState(tree)
+	restored := stateTree()
+	if err := RestoreState(restored, saved); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(SaveState(restored), saved) {
+		t.Fatalf("restored state %+v, want %+v", SaveState(restored), saved)
+	}
+	for i := 0; i < 20; i++ {
+		if a, b := tree.Tick(), restored.Tick(); a != b {
+			t.Fatalf("tick %d after restoring: %d, want %d", i, b, a)
+		}
+	}
+}
+
+func TestRestoreStateRejectsOtherTrees(t *testing.T) {
+	tree := stateTree()
+	for i := 0; i < 7; i++ {
+		tree.Tick()
+	}
+	tests := []struct {
+		name string
+		edit func(s *State)
+	}{
+		{"missing child", func(s *State) { s.Children = s.Children[:1] }},
+		{"no state", func(s *State) { s.Children[1].Children[0] = nil }},
+		{"sequence past its children", func(s *State) { s.Children[1].Values[0] = 4 }},
+		{"unknown running child", func(s *State) { s.Values[0] = 2 }},
+		{"unknown status", func(s *State) { s.Children[1].Children[2].Values = []int{7, 7} }},
+		{"action values", func(s *State) { s.Children[1].Children[0].Values = nil }},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := SaveState(tree)
+			test.edit(s)
+			fresh := stateTree()
+			want := SaveState(fresh)
+			if err := RestoreState(fresh, s); err == nil {
+				t.Fatal("restored a state that doesn't fit")
+			}
+			if !reflect.DeepEqual(SaveState(fresh), want) {
+				t.Fatal("tree changed anyway")
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package bt
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Definition describes a node of a tree as stored in a JSON file, e.g.
+//
+//	{"type": "sequence", "children": [
+//		{"type": "canSee", "params": {"radius": 4}},
+//		{"type": "moveTo", "params": {"target": "player"}}
+//	]}
+type Definition struct {
+	Type     string        `json:"type"`
+	Params   Params        `json:"params,omitempty"`
+	Children []*Definition `json:"children,omitempty"`
+}
+
+// Leaf creates a leaf node from the params of its definition.
+type Leaf func(params Params) (Node, error)
+
+// Leaves maps leaf type names to the functions creating them. Leaves are game
+// specific, so the game registers its own actions and conditions.
+type Leaves map[string]Leaf
+
+// Load reads a tree definition from r and builds it.
+func Load(r io.Reader, leaves Leaves) (Node, error) {
+	var def Definition
+	if err := json.NewDecoder(r).Decode(&def); err != nil {
+		return nil, err
+	}
+	return Build(&def, leaves)
+}
+
+// Build creates the tree described by def. Composite and decorator types are
+// built in, any other type is looked up in leaves.
+func Build(def *Definition, leaves Leaves) (Node, error) {
+	children := []Node{}
+	for _, childDef := range def.Children {
+		child, err := Build(childDef, leaves)
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, child)
+	}
+
+	switch def.Type {
+	case "sequence":
+		return &Sequence{Children: children}, nil
+	case "selector":
+		return &Selector{Children: children}, nil
+	case "reactiveSequence":
+		return &ReactiveSequence{Children: children}, nil
+	case "reactiveSelector":
+		return &ReactiveSelector{Children: children}, nil
+	case "parallel":
+		return &Parallel{
+			Children:         children,
+			SuccessThreshold: def.Params.Int("success", 0),
+			FailureThreshold: def.Params.Int("failure", 1),
+		}, nil
+	}
+
+	if decorator, ok := decorators[def.Type]; ok {
+		if len(children) != 1 {
+			return nil, fmt.Errorf("bt: %s needs exactly one child, got %d", def.Type, len(children))
+		}
+		return decorator(children[0], def.Params), nil
+	}
+
+	leaf, ok := leaves[def.Type]
+	if !ok {
+		return nil, fmt.Errorf("bt: unknown node type %q", def.Type)
+	}
+	if len(children) > 0 {
+		return nil, fmt.Errorf("bt: leaf %s cannot have children", def.Type)
+	}
+	return leaf(def.Params)
+}
+
+var decorators = map[string]func(child Node, params Params) Node{
+	"inverter":  func(child Node, params Params) Node { return &Inverter{Child: child} },
+	"succeeder": func(child Node, params Params) Node { return &Succeeder{Child: child} },
+	"repeater": func(child Node, params Params) Node {
+		return &Repeater{Child: child, Times: params.Int("times", 0)}
+	},
+	"untilFail": func(child Node, params Params) Node { return &UntilFail{Child: child} },
+}
+
+// Params holds the parameters of a node definition.
+type Params map[string]any
+
+func (p Params) Int(name string, def int) int {
+	// json numbers decode as float64
+	if v, ok := p[name].(float64); ok {
+		return int(v)
+	}
+	return def
+}
+
+func (p Params) Float(name string, def float64) float64 {
+	if v, ok := p[name].(float64); ok {
+		return v
+	}
+	return def
+}
+
+func (p Params) String(name string, def string) string {
+	if v, ok := p[name].(string); ok {
+		return v
+	}
+	return def
+}
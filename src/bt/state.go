@@ -0,0 +1,174 @@
+package bt
+
+import (
+	"fmt"
+	"math"
+)
+
+// State is where a node is in running, along with the states of its
+// children, so a tree can be saved and put back where it was. It mirrors the
+// shape of the tree: a state only fits a tree built from the same definition.
+type State struct {
+	Values   []int    `json:"values,omitempty"`
+	Children []*State `json:"children,omitempty"`
+}
+
+// SaveState returns the state of n and everything below it.
+func SaveState(n Node) *State {
+	s := &State{}
+	switch n := n.(type) {
+	case *Sequence:
+		s.Values = []int{n.current}
+	case *Selector:
+		s.Values = []int{n.current}
+	case *ReactiveSequence:
+		s.Values = []int{indexOf(n.Children, n.running)}
+	case *ReactiveSelector:
+		s.Values = []int{indexOf(n.Children, n.running)}
+	case *Parallel:
+		for _, status := range n.done {
+			s.Values = append(s.Values, int(status))
+		}
+	case *Repeater:
+		s.Values = []int{n.count}
+	case *Action:
+		if n.Save != nil {
+			s.Values = n.Save()
+		}
+	}
+	for _, child := range children(n) {
+		s.Children = append(s.Children, SaveState(child))
+	}
+	return s
+}
+
+// RestoreState puts n and everything below it back in state s. Nothing is
+// changed if s doesn't fit the tree.
+func RestoreState(n Node, s *State) error {
+	if err := checkState(n, s); err != nil {
+		return err
+	}
+	restoreState(n, s)
+	return nil
+}
+
+func checkState(n Node, s *State) error {
+	if s == nil {
+		return fmt.Errorf("bt: no state for %T", n)
+	}
+	nodes := children(n)
+	if len(s.Children) != len(nodes) {
+		return fmt.Errorf("bt: state of %T has %d children, not %d", n, len(s.Children), len(nodes))
+	}
+
+	// the range of each value, the same for all of them
+	want, low, high := 0, 0, 0
+	switch n := n.(type) {
+	case *Sequence, *Selector:
+		want, low, high = 1, 0, len(nodes)
+	case *ReactiveSequence, *ReactiveSelector:
+		want, low, high = 1, -1, len(nodes)-1
+	case *Repeater:
+		want, low, high = 1, 0, math.MaxInt
+	case *Parallel:
+		// nothing is done before the first tick
+		want, low, high = len(nodes), int(Success), int(Running)
+		if len(s.Values) == 0 {
+			want = 0
+		}
+	case *Action:
+		// only the action knows what its values mean, but it always saves as
+		// many
+		low, high = math.MinInt, math.MaxInt
+		if n.Save != nil {
+			want = len(n.Save())
+		}
+		if n.Save != nil && n.Restore == nil {
+			return fmt.Errorf("bt: action saves its state but can't restore it")
+		}
+	}
+	if len(s.Values) != want {
+		return fmt.Errorf("bt: state of %T has %d values, not %d", n, len(s.Values), want)
+	}
+	for _, v := range s.Values {
+		if v < low || v > high {
+			return fmt.Errorf("bt: %T can't be in state %d", n, v)
+		}
+	}
+
+	for i, child := range nodes {
+		if err := checkState(child, s.Children[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func restoreState(n Node, s *State) {
+	switch n := n.(type) {
+	case *Sequence:
+		n.current = s.Values[0]
+	case *Selector:
+		n.current = s.Values[0]
+	case *ReactiveSequence:
+		n.running = childAt(n.Children, s.Values[0])
+	case *ReactiveSelector:
+		n.running = childAt(n.Children, s.Values[0])
+	case *Parallel:
+		n.done = nil
+		for _, status := range s.Values {
+			n.done = append(n.done, Status(status))
+		}
+	case *Repeater:
+		n.count = s.Values[0]
+	case *Action:
+		if n.Restore != nil {
+			n.Restore(s.Values)
+		}
+	}
+	for i, child := range children(n) {
+		restoreState(child, s.Children[i])
+	}
+}
+
+// children returns the children of the built in composites and decorators.
+func children(n Node) []Node {
+	switch n := n.(type) {
+	case *Sequence:
+		return n.Children
+	case *Selector:
+		return n.Children
+	case *ReactiveSequence:
+		return n.Children
+	case *ReactiveSelector:
+		return n.Children
+	case *Parallel:
+		return n.Children
+	case *Inverter:
+		return []Node{n.Child}
+	case *Succeeder:
+		return []Node{n.Child}
+	case *Repeater:
+		return []Node{n.Child}
+	case *UntilFail:
+		return []Node{n.Child}
+	}
+	return nil
+}
+
+// indexOf returns the index of n in nodes, -1 if it isn't one of them.
+func indexOf(nodes []Node, n Node) int {
+	for i, node := range nodes {
+		if node == n {
+			return i
+		}
+	}
+	return -1
+}
+
+func childAt(nodes []Node, i int) Node {
+	if i < 0 {
+		return nil
+	}
+	return nodes[i]
+}
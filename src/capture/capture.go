@@ -0,0 +1,139 @@
+// Package capture records frames, of the game screen or of images drawn
+// without a window, and saves them as an animated GIF or a sequence of PNG
+// files with the standard library encoders.
+package capture
+
+import (
+	"fmt"
+	"image"
+	"image/color/palette"
+	"image/gif"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Capture keeps one frame out of every Every it is offered, up to MaxFrames.
+type Capture struct {
+	Every     int
+	MaxFrames int
+	Delay     int               // time between frames of a GIF, in hundredths of a second
+	frames    []*image.Paletted // paletted as they come, a quarter of the memory of RGBA
+	offered   int
+}
+
+func New(every, maxFrames, delay int) *Capture {
+	return &Capture{Every: max(every, 1), MaxFrames: maxFrames, Delay: delay}
+}
+
+// Frame is called once per frame. render is only called for the frames kept,
+// since reading back the screen or drawing the grid costs time.
+func (c *Capture) Frame(render func() image.Image) {
+	c.offered += 1
+	if (c.offered-1)%c.Every == 0 {
+		c.Add(render())
+	}
+}
+
+// Add keeps frame whatever Every says, e.g. the last frame of a search. It is
+// converted to the palette of the GIF straight away, so the PNGs get the same
+// colours.
+func (c *Capture) Add(frame image.Image) {
+	if !c.Full() {
+		c.frames = append(c.frames, paletted(frame))
+	}
+}
+
+// Len returns the number of frames kept.
+func (c *Capture) Len() int {
+	return len(c.frames)
+}
+
+// Full reports whether MaxFrames have been kept. Any more are dropped.
+func (c *Capture) Full() bool {
+	return c.MaxFrames > 0 && len(c.frames) >= c.MaxFrames
+}
+
+// Save writes the frames to name. A .gif name gets an animated GIF, a .png
+// name a numbered file per frame, e.g. run_0001.png, run_0002.png and so on.
+func (c *Capture) Save(name string) error {
+	if len(c.frames) == 0 {
+		return fmt.Errorf("%s: no frames captured", name)
+	}
+	switch filepath.Ext(name) {
+	case ".gif":
+		return c.saveGIF(name)
+	case ".png":
+		return c.savePNGs(name)
+	}
+	return fmt.Errorf("%s: can only capture to .gif or .png", name)
+}
+
+func (c *Capture) saveGIF(name string) error {
+	animation := &gif.GIF{}
+	for _, frame := range c.frames {
+		animation.Image = append(animation.Image, frame)
+		animation.Delay = append(animation.Delay, c.Delay)
+	}
+	// hold the last frame so the end can be seen before the animation loops
+	animation.Delay[len(animation.Delay)-1] = max(c.Delay, 200)
+
+	file, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if err := gif.EncodeAll(file, animation); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func (c *Capture) savePNGs(name string) error {
+	prefix := strings.TrimSuffix(name, ".png")
+	for i, frame := range c.frames {
+		file, err := os.Create(fmt.Sprintf("%s_%04d.png", prefix, i+1))
+		if err != nil {
+			return err
+		}
+		if err := png.Encode(file, frame); err != nil {
+			file.Close()
+			return err
+		}
+		if err := file.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// paletted converts frame to the web safe palette. Each channel is rounded to
+// the nearest of its 6 levels, which is much faster than searching the palette
+// for the closest colour of every pixel.
+func paletted(frame image.Image) *image.Paletted {
+	bounds := frame.Bounds()
+	img := image.NewPaletted(bounds, palette.WebSafe)
+	level := func(v uint8) uint8 { return uint8((uint32(v) + 25) / 51) }
+
+	// the screen and the rendered grid are RGBA, read their pixels in place
+	// rather than boxing a colour for every one
+	if rgba, ok := frame.(*image.RGBA); ok {
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			src := rgba.Pix[rgba.PixOffset(bounds.Min.X, y):]
+			dst := img.Pix[img.PixOffset(bounds.Min.X, y):]
+			for x := 0; x < bounds.Dx(); x++ {
+				dst[x] = level(src[x*4])*36 + level(src[x*4+1])*6 + level(src[x*4+2])
+			}
+		}
+		return img
+	}
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, b, _ := frame.At(x, y).RGBA()
+			img.SetColorIndex(x, y, level(uint8(r>>8))*36+level(uint8(g>>8))*6+level(uint8(b>>8)))
+		}
+	}
+	return img
+}
@@ -0,0 +1,82 @@
+package capture
+
+import (
+	"image"
+	"image/color"
+	"image/gif"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCaptureKeepsPalettedFrames(t *testing.T) {
+	c := New(2, 2, 5)
+	red := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	for i := range red.Pix {
+		red.Pix[i] = []uint8{0xff, 0, 0, 0xff}[i%4]
+	}
+	for i := 0; i < 5; i++ {
+		c.Frame(func() image.Image { return red })
+	}
+	if c.Len() != 2 || !c.Full() {
+		t.Fatalf("kept %d frames, want 2", c.Len())
+	}
+	if r, g, b, _ := c.frames[0].At(0, 0).RGBA(); r != 0xffff || g != 0 || b != 0 {
+		t.Fatalf("frame is %v, not red", c.frames[0].At(0, 0))
+	}
+
+	name := filepath.Join(t.TempDir(), "run.gif")
+	if err := c.Save(name); err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	animation, err := gif.DecodeAll(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(animation.Image) != 2 {
+		t.Fatalf("gif has %d frames, want 2", len(animation.Image))
+	}
+	if got := color.NRGBAModel.Convert(animation.Image[1].At(3, 3)); got != (color.NRGBA{0xff, 0, 0, 0xff}) {
+		t.Fatalf("gif frame is %v, not red", got)
+	}
+}
+
+func TestPalettedReadsRGBAInPlace(t *testing.T) {
+	frame := image.NewRGBA(image.Rect(0, 0, 40, 30))
+	r := rand.New(rand.NewSource(1))
+	r.Read(frame.Pix)
+	for i := 3; i < len(frame.Pix); i += 4 {
+		frame.Pix[i] = 0xff
+	}
+	// a sub-image shares the pixels of the whole frame but starts elsewhere
+	sub := frame.SubImage(image.Rect(7, 5, 33, 21))
+
+	for _, img := range []image.Image{frame, sub} {
+		got := paletted(img)
+		// without the RGBA type, paletted goes through At
+		want := paletted(struct{ image.Image }{img})
+		if got.Bounds() != img.Bounds() || want.Bounds() != img.Bounds() {
+			t.Fatalf("paletted bounds %v, want %v", got.Bounds(), img.Bounds())
+		}
+		for y := img.Bounds().Min.Y; y < img.Bounds().Max.Y; y++ {
+			for x := img.Bounds().Min.X; x < img.Bounds().Max.X; x++ {
+				if got.ColorIndexAt(x, y) != want.ColorIndexAt(x, y) {
+					t.Fatalf("pixel %d,%d is colour %d, want %d", x, y, got.ColorIndexAt(x, y), want.ColorIndexAt(x, y))
+				}
+			}
+		}
+	}
+}
+
+func BenchmarkPaletted(b *testing.B) {
+	frame := image.NewRGBA(image.Rect(0, 0, 960, 640))
+	for i := 0; i < b.N; i++ {
+		paletted(frame)
+	}
+}
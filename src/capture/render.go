@@ -0,0 +1,82 @@
+package capture
+
+import (
+	"a-star/src/astar"
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+var (
+	floorColor  = color.NRGBA{0xf0, 0xf0, 0xe8, 0xff}
+	wallColor   = color.NRGBA{0x40, 0x40, 0x40, 0xff}
+	costlyColor = color.NRGBA{0xa0, 0x80, 0x50, 0xff} // ground that costs more than 1, faded by its cost
+	closedColor = color.NRGBA{0x20, 0x40, 0xa0, 0x60}
+	openColor   = color.NRGBA{0x20, 0xa0, 0x40, 0x60}
+	pathColor   = color.NRGBA{0xe0, 0xa0, 0x20, 0xc0}
+	originColor = color.NRGBA{0x20, 0xa0, 0x20, 0xff}
+	destColor   = color.NRGBA{0xd0, 0x20, 0x20, 0xff}
+)
+
+// Grid draws m with every cell size pixels square: walls, plain ground and
+// ground that costs more to cross.
+func Grid(m *astar.GridMap, size int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, m.Width*size, m.Height*size))
+	draw.Draw(img, img.Bounds(), image.NewUniform(floorColor), image.Point{}, draw.Src)
+
+	maxCost := 1.0
+	for y := range m.Cells {
+		for _, cell := range m.Cells[y] {
+			if cell.IsWalkable {
+				maxCost = max(maxCost, cell.Cost)
+			}
+		}
+	}
+	for y := range m.Cells {
+		for _, cell := range m.Cells[y] {
+			if !cell.IsWalkable {
+				FillCell(img, cell, size, wallColor)
+			} else if cell.Cost > 1 {
+				c := costlyColor
+				c.A = uint8(0x40 + 0xa0*(cell.Cost-1)/(maxCost-1))
+				FillCell(img, cell, size, c)
+			}
+		}
+	}
+	return img
+}
+
+// Search draws the state of s over its map: the cells expanded so far, the
+// ones waiting to be, and the path once found.
+func Search(s *astar.Search, size int) *image.RGBA {
+	img := Grid(s.Map, size)
+	for _, cell := range s.ClosedCells() {
+		FillCell(img, cell, size, closedColor)
+	}
+	for _, cell := range s.OpenCells() {
+		FillCell(img, cell, size, openColor)
+	}
+	if s.Path != nil {
+		for _, cell := range s.Path.Cells {
+			FillCell(img, cell, size, pathColor)
+		}
+	}
+	Marker(img, s.Origin, size, originColor)
+	if s.Dest != nil {
+		Marker(img, s.Dest, size, destColor)
+	}
+	return img
+}
+
+// FillCell blends c over the whole of cell.
+func FillCell(img draw.Image, cell *astar.Cell, size int, c color.Color) {
+	rect := image.Rect(cell.X*size, cell.Y*size, (cell.X+1)*size, (cell.Y+1)*size)
+	draw.Draw(img, rect, image.NewUniform(c), image.Point{}, draw.Over)
+}
+
+// Marker draws a square of c in the middle of cell, half its size.
+func Marker(img draw.Image, cell *astar.Cell, size int, c color.Color) {
+	x, y := cell.X*size+size/4, cell.Y*size+size/4
+	rect := image.Rect(x, y, x+size/2, y+size/2)
+	draw.Draw(img, rect, image.NewUniform(c), image.Point{}, draw.Over)
+}
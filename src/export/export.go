@@ -0,0 +1,124 @@
+// Package export writes a grid map with a path found on it and the trace of
+// the search that found it, as JSON for other tools and as SVG for documents.
+package export
+
+import (
+	"a-star/src/astar"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Export is a search from origin to dest on a grid map. Each row of Walls has a
+// # for a wall and a . for any other cell, costs are indexed by y first like
+// GridMap.Cells.
+type Export struct {
+	Algorithm  string      `json:"algorithm"`
+	Heuristic  string      `json:"heuristic"`
+	Width      int         `json:"width"`
+	Height     int         `json:"height"`
+	Walls      []string    `json:"walls"`
+	Costs      [][]float64 `json:"costs"`
+	Origin     [2]int      `json:"origin"`
+	Dest       [2]int      `json:"dest"`
+	Found      bool        `json:"found"`
+	Cost       float64     `json:"cost"`
+	Path       [][2]int    `json:"path"` // x, y of each cell, without the origin
+	Expanded   int         `json:"expanded"`
+	Expansions []Expansion `json:"expansions,omitempty"`
+}
+
+// Expansion is a node the search expanded, numbered from 1 in order. Searches
+// that expand a cell more than once, like IDA*, list it each time.
+type Expansion struct {
+	Order int     `json:"order"`
+	X     int     `json:"x"`
+	Y     int     `json:"y"`
+	G     float64 `json:"g"`
+	H     float64 `json:"h"`
+	F     float64 `json:"f"`
+}
+
+// New runs algorithm from origin to dest on m and keeps what it did.
+func New(m *astar.GridMap, origin, dest *astar.Cell, algorithm astar.Algorithm, heuristic astar.NamedHeuristic) *Export {
+	e := &Export{
+		Algorithm: algorithm.Name,
+		Heuristic: heuristic.Name,
+		Width:     m.Width,
+		Height:    m.Height,
+		Origin:    [2]int{origin.X, origin.Y},
+		Dest:      [2]int{dest.X, dest.Y},
+	}
+	for y := range m.Cells {
+		walls, costs := []byte{}, []float64{}
+		for _, cell := range m.Cells[y] {
+			if cell.IsWalkable {
+				walls = append(walls, '.')
+			} else {
+				walls = append(walls, '#')
+			}
+			costs = append(costs, cell.Cost)
+		}
+		e.Walls = append(e.Walls, string(walls))
+		e.Costs = append(e.Costs, costs)
+	}
+
+	options := astar.DefaultSearchOptions
+	options.Heuristic = heuristic.Func
+	options.Trace = func(expansion astar.Expansion) {
+		e.Expansions = append(e.Expansions, Expansion{
+			Order: len(e.Expansions) + 1,
+			X:     expansion.Cell.X,
+			Y:     expansion.Cell.Y,
+			G:     expansion.G,
+			H:     expansion.H,
+			F:     expansion.F,
+		})
+	}
+
+	var path *astar.Path
+	path, e.Expanded = algorithm.Search(m, origin, dest, options)
+
+	if path != nil {
+		e.Found = true
+		e.Cost = path.Cost()
+		for _, cell := range path.Cells {
+			e.Path = append(e.Path, [2]int{cell.X, cell.Y})
+		}
+	}
+	return e
+}
+
+func (e *Export) WriteJSON(w io.Writer) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// Save writes the export to name, as JSON or SVG depending on its extension.
+func (e *Export) Save(name string) error {
+	var write func(w io.Writer) error
+	switch filepath.Ext(name) {
+	case ".json":
+		write = e.WriteJSON
+	case ".svg":
+		write = e.WriteSVG
+	default:
+		return fmt.Errorf("%s: can only export to .json or .svg", name)
+	}
+
+	file, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if err := write(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,116 @@
+package export
+
+import (
+	"a-star/src/astar"
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+const testGrid = `
+	#######
+	#S..3.#
+	#.##9.#
+	#....G#
+	#######
+`
+
+func findAlgorithm(t *testing.T, name string) astar.Algorithm {
+	t.Helper()
+	for _, algorithm := range astar.Algorithms {
+		if algorithm.Name == name {
+			return algorithm
+		}
+	}
+	t.Fatalf("no algorithm %s", name)
+	return astar.Algorithm{}
+}
+
+// TestGoldenExports exports searches on a small grid and compares the JSON
+// and SVG with the files in testdata. Run with -update to write them again
+// after a deliberate change.
+func TestGoldenExports(t *testing.T) {
+	m, origin, goal, err := astar.ParseGrid(testGrid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		algorithm string
+		golden    string
+	}{
+		{"A*", "astar"},
+		{"IDA*", "idastar"},
+	}
+	for _, test := range tests {
+		t.Run(test.algorithm, func(t *testing.T) {
+			e := New(m, origin, goal, findAlgorithm(t, test.algorithm), astar.Heuristics[0])
+			outputs := []struct {
+				ext   string
+				write func(w *bytes.Buffer) error
+			}{
+				{".json", func(w *bytes.Buffer) error { return e.WriteJSON(w) }},
+				{".svg", func(w *bytes.Buffer) error { return e.WriteSVG(w) }},
+			}
+			for _, output := range outputs {
+				var got bytes.Buffer
+				if err := output.write(&got); err != nil {
+					t.Fatal(err)
+				}
+				golden := filepath.Join("testdata", test.golden+output.ext)
+				if *update {
+					if err := os.WriteFile(golden, got.Bytes(), 0644); err != nil {
+						t.Fatal(err)
+					}
+				}
+				want, err := os.ReadFile(golden)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(got.Bytes(), want) {
+					t.Fatalf("export differs from %s, got\n%s", golden, got.String())
+				}
+			}
+		})
+	}
+}
+
+func TestEveryAlgorithmIsTraced(t *testing.T) {
+	m, origin, goal, err := astar.ParseGrid(strings.ReplaceAll(testGrid, "3", "."))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, algorithm := range astar.Algorithms {
+		if !algorithm.Searches(m) {
+			continue
+		}
+		e := New(m, origin, goal, algorithm, astar.Heuristics[0])
+		if !e.Found {
+			t.Errorf("%s found no path", algorithm.Name)
+		}
+		if len(e.Expansions) == 0 || len(e.Expansions) != e.Expanded {
+			t.Errorf("%s traced %d of %d expansions", algorithm.Name, len(e.Expansions), e.Expanded)
+		}
+		for i, expansion := range e.Expansions {
+			if expansion.Order != i+1 {
+				t.Errorf("%s expansion %d is numbered %d", algorithm.Name, i+1, expansion.Order)
+				break
+			}
+		}
+	}
+}
+
+func TestSaveRejectsOtherFormats(t *testing.T) {
+	m, origin, goal, err := astar.ParseGrid(testGrid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := New(m, origin, goal, astar.Algorithms[0], astar.Heuristics[0])
+	if err := e.Save(filepath.Join(t.TempDir(), "search.png")); err == nil {
+		t.Fatal("saved a search as png")
+	}
+}
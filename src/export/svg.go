@@ -0,0 +1,86 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// size of a cell in the svg, in pixels
+const svgCellSize = 16
+
+// WriteSVG draws the map with walls in dark grey and costly ground in shades
+// of brown. Expanded cells are coloured from blue to red in the order the
+// search expanded them, each with its g, h and f as a tooltip, and the path
+// is drawn over them as a line from origin to dest.
+func (e *Export) WriteSVG(w io.Writer) error {
+	out := bufio.NewWriter(w)
+	width, height := e.Width*svgCellSize, e.Height*svgCellSize
+	fmt.Fprintf(out, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+"\n",
+		width, height, width, height)
+	fmt.Fprintf(out, "<title>%s with the %s heuristic from %d,%d to %d,%d</title>\n",
+		e.Algorithm, e.Heuristic, e.Origin[0], e.Origin[1], e.Dest[0], e.Dest[1])
+	fmt.Fprintf(out, `<rect width="%d" height="%d" fill="#ffffff"/>`+"\n", width, height)
+
+	maxCost := 1.0
+	for y := range e.Costs {
+		for x, cost := range e.Costs[y] {
+			if e.Walls[y][x] != '#' {
+				maxCost = max(maxCost, cost)
+			}
+		}
+	}
+	for y := range e.Walls {
+		for x := range e.Walls[y] {
+			if e.Walls[y][x] == '#' {
+				writeCell(out, x, y, "#404040")
+			} else if cost := e.Costs[y][x]; cost > 1 {
+				// the darker the brown, the more the cell costs
+				lightness := 90 - 30*(cost-1)/(maxCost-1)
+				writeCell(out, x, y, fmt.Sprintf("hsl(30,40%%,%.0f%%)", lightness))
+			}
+		}
+	}
+
+	for _, expansion := range e.Expansions {
+		hue := 240.0
+		if len(e.Expansions) > 1 {
+			hue = 240 * (1 - float64(expansion.Order-1)/float64(len(e.Expansions)-1))
+		}
+		fmt.Fprintf(out, `<rect x="%d" y="%d" width="%d" height="%d" fill="hsl(%.0f,80%%,60%%)" fill-opacity="0.6">`,
+			expansion.X*svgCellSize, expansion.Y*svgCellSize, svgCellSize, svgCellSize, hue)
+		fmt.Fprintf(out, "<title>#%d at %d,%d: g=%g h=%g f=%g</title></rect>\n",
+			expansion.Order, expansion.X, expansion.Y, expansion.G, expansion.H, expansion.F)
+	}
+
+	if len(e.Path) > 0 {
+		points := []string{center(e.Origin)}
+		for _, cell := range e.Path {
+			points = append(points, center(cell))
+		}
+		fmt.Fprintf(out, `<polyline points="%s" fill="none" stroke="#000000" stroke-width="3" stroke-linejoin="round" stroke-linecap="round"/>`+"\n",
+			strings.Join(points, " "))
+	}
+	writeMarker(out, e.Origin, "#20a020", "origin")
+	writeMarker(out, e.Dest, "#d02020", "dest")
+
+	fmt.Fprintln(out, "</svg>")
+	return out.Flush()
+}
+
+func writeCell(w io.Writer, x, y int, fill string) {
+	fmt.Fprintf(w, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s"/>`+"\n",
+		x*svgCellSize, y*svgCellSize, svgCellSize, svgCellSize, fill)
+}
+
+func writeMarker(w io.Writer, cell [2]int, fill, title string) {
+	x, y := cell[0]*svgCellSize+svgCellSize/2, cell[1]*svgCellSize+svgCellSize/2
+	fmt.Fprintf(w, `<circle cx="%d" cy="%d" r="%d" fill="%s" stroke="#000000"><title>%s</title></circle>`+"\n",
+		x, y, svgCellSize/3, fill, title)
+}
+
+// center returns the middle of a cell as an svg point.
+func center(cell [2]int) string {
+	return fmt.Sprintf("%d,%d", cell[0]*svgCellSize+svgCellSize/2, cell[1]*svgCellSize+svgCellSize/2)
+}
@@ -0,0 +1,183 @@
+package game
+
+import (
+	"a-star/src/astar"
+	"a-star/src/utils"
+	"math"
+
+	"github.com/lafriks/go-tiled"
+)
+
+// BehaviourConfig holds how a chicken reacts to the player. It is read from
+// the properties of the chicken's spawn point in Tiled.
+type BehaviourConfig struct {
+	Reaction     int // behaviour to switch to when the player is in sight
+	SightRadius  int
+	WanderRadius int
+	FleeRadius   int
+	RestTime     int
+}
+
+func NewBehaviourConfig(spawnPoint *tiled.Object) BehaviourConfig {
+	config := BehaviourConfig{
+		Reaction:     utils.BehaviourWander,
+		SightRadius:  utils.ChickenSightRadius,
+		WanderRadius: utils.ChickenWanderRadius,
+		FleeRadius:   utils.ChickenFleeRadius,
+		RestTime:     utils.ChickenRestTime,
+	}
+
+	switch spawnPoint.Properties.GetString("reaction") {
+	case "chase":
+		config.Reaction = utils.BehaviourChase
+	case "flee":
+		config.Reaction = utils.BehaviourFlee
+	}
+	if v := spawnPoint.Properties.GetInt("sight"); v > 0 {
+		config.SightRadius = v
+	}
+	if v := spawnPoint.Properties.GetInt("wander_radius"); v > 0 {
+		config.WanderRadius = v
+	}
+	if v := spawnPoint.Properties.GetInt("flee_radius"); v > 0 {
+		config.FleeRadius = v
+	}
+	if v := spawnPoint.Properties.GetInt("rest_time"); v > 0 {
+		config.RestTime = v
+	}
+	return config
+}
+
+// SetBehaviour switches the chicken to behaviour, dropping its current path.
+// A forced behaviour ignores the player's distance until it is finished, and
+// a chicken with a behaviour tree starts it over afterwards.
+func (c *Chicken) SetBehaviour(behaviour int, forced bool) {
+	if forced && c.Tree != nil {
+		c.Tree.Reset()
+	}
+	c.Behaviour = behaviour
+	c.BehaviourForced = forced
+	c.BehaviourTTL = 0
+	c.Path = nil
+}
+
+func updateBehaviour(g *Game, c *Chicken) {
+	if c.BehaviourTTL > 0 {
+		c.BehaviourTTL -= 1
+	}
+
+	cx, cy := c.GetCenterPoint()
+	px, py := g.Player.GetCenterPoint()
+	chickenCell := astar.GetCell(cx, cy)
+	playerCell := astar.GetCell(px, py)
+
+	// only change course on a cell so chickens don't cut corners
+	if !c.IsOnCell() {
+		if c.HasArrived() {
+			// finish stepping onto the nearest cell first
+			c.Path = &astar.Path{Cells: []*astar.Cell{chickenCell}}
+		}
+		return
+	}
+	distance := int(astar.Heuristic(chickenCell, playerCell))
+	inSight := distance <= c.Config.SightRadius
+
+	// react to the player coming near
+	if !c.BehaviourForced && inSight && c.Config.Reaction != utils.BehaviourWander &&
+		c.Behaviour != c.Config.Reaction {
+		c.SetBehaviour(c.Config.Reaction, false)
+	}
+
+	switch c.Behaviour {
+	case utils.BehaviourRest:
+		if c.BehaviourTTL == 0 {
+			c.SetBehaviour(utils.BehaviourWander, false)
+		}
+
+	case utils.BehaviourWander:
+		if c.Path != nil && c.HasArrived() {
+			c.rest()
+		} else if c.Path == nil {
+			if c.Patrol != nil {
+				c.SetPathToCell(g, c.Patrol.Next(g.Rand))
+			} else {
+				c.wander(g, chickenCell, c.Config.WanderRadius)
+			}
+			if c.Path == nil {
+				c.rest()
+			}
+		}
+
+	case utils.BehaviourChase:
+		if c.BehaviourForced && distance == 0 {
+			// caught up, stay with the player until they move on
+			c.Path = nil
+			if c.State == utils.ChickenWalkState {
+				c.State = utils.ChickenIdleState
+			}
+		} else if !c.BehaviourForced && distance <= 1 {
+			c.rest()
+		} else if !c.BehaviourForced && distance > c.Config.SightRadius*2 {
+			// lost sight of the player
+			c.SetBehaviour(utils.BehaviourWander, false)
+		} else if c.HasArrived() || c.BehaviourTTL == 0 {
+			// the player keeps moving, so look for them again now and then
+			c.BehaviourTTL = utils.ChickenRepathDelay
+			if c.BehaviourForced {
+				// every chicken is chasing, right up to the player
+				c.SetPath(g, px, py)
+			} else if c.SetPathToGoal(g, astar.NewGoalWithin(g.GridMap, playerCell, 1)) == nil {
+				// any cell next to the player will do, but there is no way to one
+				c.SetBehaviour(utils.BehaviourWander, false)
+			}
+		}
+
+	case utils.BehaviourFlee:
+		if c.Path != nil && c.HasArrived() {
+			if inSight {
+				c.Path = nil
+			} else {
+				c.rest()
+			}
+		} else if c.Path == nil {
+			c.flee(g, chickenCell, playerCell, c.Config.FleeRadius)
+			if c.Path == nil {
+				// cornered
+				c.rest()
+			}
+		}
+	}
+}
+
+func (c *Chicken) rest() {
+	c.SetBehaviour(utils.BehaviourRest, false)
+	c.BehaviourTTL = c.Config.RestTime
+	if c.State == utils.ChickenWalkState {
+		c.State = utils.ChickenIdleState
+	}
+}
+
+// wander walks to a random cell within radius.
+func (c *Chicken) wander(g *Game, chickenCell *astar.Cell, radius int) {
+	cells := astar.ReachableWithin(g.GridMap, chickenCell, float64(radius))
+	if len(cells) <= 1 {
+		return
+	}
+	c.SetPathToCell(g, cells[g.Rand.Intn(len(cells))])
+}
+
+// flee walks to the reachable cell within radius that is furthest from the
+// player.
+func (c *Chicken) flee(g *Game, chickenCell, playerCell *astar.Cell, radius int) {
+	var best *astar.Cell
+	bestDistance := math.Inf(-1)
+	for _, cell := range astar.ReachableWithin(g.GridMap, chickenCell, float64(radius)) {
+		if d := astar.Heuristic(cell, playerCell); d > bestDistance {
+			best, bestDistance = cell, d
+		}
+	}
+	if best == nil || bestDistance <= astar.Heuristic(chickenCell, playerCell) {
+		return
+	}
+	c.SetPathToCell(g, best)
+}
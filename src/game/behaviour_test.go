@@ -0,0 +1,185 @@
+package game
+
+import (
+	"a-star/src/astar"
+	"a-star/src/utils"
+	"fmt"
+	"math"
+	"testing"
+)
+
+// freeChicken returns a chicken without a behaviour tree or patrol standing
+// on a cell.
+func freeChicken(t *testing.T, g *Game) *Chicken {
+	t.Helper()
+	for _, c := range g.Chickens {
+		if c.Tree == nil && c.Patrol == nil && c.IsOnCell() {
+			return c
+		}
+	}
+	t.Fatal("no free chicken on a cell")
+	return nil
+}
+
+// movePlayerNear puts the player on a walkable cell distance steps from the
+// chicken, as the crow flies, that the chicken can walk to.
+func movePlayerNear(t *testing.T, g *Game, c *Chicken, distance int) {
+	t.Helper()
+	cx, cy := c.GetCenterPoint()
+	chickenCell := astar.GetCell(cx, cy)
+	for _, cell := range astar.ReachableWithin(g.GridMap, chickenCell, math.Inf(1)) {
+		if int(astar.Heuristic(cell, chickenCell)) != distance {
+			continue
+		}
+		px, py := g.Player.GetCenterPoint()
+		g.Player.Dx = cell.X*utils.UnitSize + utils.UnitSize/2 - px
+		g.Player.Dy = cell.Y*utils.UnitSize + utils.UnitSize/2 - py
+		g.Player.UpdateLocation()
+		return
+	}
+	t.Fatalf("no cell %d from the chicken", distance)
+}
+
+func TestBehaviourTransitions(t *testing.T) {
+	tests := []struct {
+		name      string
+		reaction  int
+		behaviour int // before the update
+		ttl       int
+		forced    bool
+		distance  int // from the player
+		want      int
+		wantPath  bool
+	}{
+		{"wander while the player is away", utils.BehaviourWander, utils.BehaviourWander, 0, false, 10, utils.BehaviourWander, true},
+		{"wanderer ignores the player", utils.BehaviourWander, utils.BehaviourWander, 0, false, 2, utils.BehaviourWander, true},
+		{"chase in sight", utils.BehaviourChase, utils.BehaviourWander, 0, false, 3, utils.BehaviourChase, true},
+		{"rest on catching up", utils.BehaviourChase, utils.BehaviourChase, 0, false, 1, utils.BehaviourRest, false},
+		{"give up out of sight", utils.BehaviourChase, utils.BehaviourChase, 0, false, 11, utils.BehaviourWander, false},
+		{"forced chase never gives up", utils.BehaviourWander, utils.BehaviourChase, 0, true, 11, utils.BehaviourChase, true},
+		{"flee in sight", utils.BehaviourFlee, utils.BehaviourRest, 50, false, 2, utils.BehaviourFlee, true},
+		{"keep resting", utils.BehaviourWander, utils.BehaviourRest, 2, false, 10, utils.BehaviourRest, false},
+		{"wake up after resting", utils.BehaviourWander, utils.BehaviourRest, 1, false, 10, utils.BehaviourWander, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := newTestGame(t, "map.tmx")
+			c := freeChicken(t, g)
+			c.Config.Reaction = test.reaction
+			c.Config.SightRadius = 5
+			movePlayerNear(t, g, c, test.distance)
+			c.SetBehaviour(test.behaviour, test.forced)
+			c.BehaviourTTL = test.ttl
+
+			updateBehaviour(g, c)
+			if c.Behaviour != test.want {
+				t.Fatalf("behaviour %d, want %d", c.Behaviour, test.want)
+			}
+			if hasPath := c.Path != nil && len(c.Path.Cells) > 0; hasPath != test.wantPath {
+				t.Fatalf("path %v, want one: %v", c.Path, test.wantPath)
+			}
+			if test.want == utils.BehaviourRest && test.behaviour != utils.BehaviourRest && c.BehaviourTTL != c.Config.RestTime {
+				t.Fatalf("resting for %d ticks, want %d", c.BehaviourTTL, c.Config.RestTime)
+			}
+		})
+	}
+}
+
+func TestFleeGetsFurtherAway(t *testing.T) {
+	g := newTestGame(t, "map.tmx")
+	c := freeChicken(t, g)
+	c.Config.Reaction = utils.BehaviourFlee
+	movePlayerNear(t, g, c, 2)
+
+	updateBehaviour(g, c)
+	if c.Behaviour != utils.BehaviourFlee || c.Path == nil || len(c.Path.Cells) == 0 {
+		t.Fatalf("behaviour %d with path %v, want to flee", c.Behaviour, c.Path)
+	}
+	cx, cy := c.GetCenterPoint()
+	dest, player := c.Path.Cells[len(c.Path.Cells)-1], playerCell(g)
+	if astar.Heuristic(dest, player) <= astar.Heuristic(astar.GetCell(cx, cy), player) {
+		t.Fatalf("fleeing to %d,%d, no further from the player", dest.X, dest.Y)
+	}
+}
+
+func TestChaseStopsNextToPlayer(t *testing.T) {
+	g := newTestGame(t, "map.tmx")
+	var c *Chicken
+	for _, chicken := range g.Chickens {
+		if chicken.Tree == nil && chicken.IsOnCell() {
+			c = chicken
+			break
+		}
+	}
+	if c == nil {
+		t.Fatal("no chicken without a behaviour tree on a cell")
+	}
+	// see the player from anywhere on the map, and chase them
+	c.Config.SightRadius = g.GameMap.Width + g.GameMap.Height
+	c.Config.Reaction = utils.BehaviourChase
+
+	updateBehaviour(g, c)
+	if c.Behaviour != utils.BehaviourChase || c.Path == nil || len(c.Path.Cells) == 0 {
+		t.Fatalf("behaviour %d with path %v, want a chase", c.Behaviour, c.Path)
+	}
+	dest, player := c.Path.Cells[len(c.Path.Cells)-1], playerCell(g)
+	if astar.Heuristic(dest, player) != 1 {
+		t.Errorf("path ends at %d,%d, want next to the player at %d,%d", dest.X, dest.Y, player.X, player.Y)
+	}
+}
+
+func TestChaseUsesTheSelectedAlgorithm(t *testing.T) {
+	g := newTestGame(t, "map.tmx")
+	c := freeChicken(t, g)
+	// greedy best-first takes the long way round from there
+	c.Restart(g, 28*utils.UnitSize, 8*utils.UnitSize)
+	px, py := g.Player.GetCenterPoint()
+	origin, dest := g.GridMap.GetGridCell(28, 8), astar.GetCell(px, py)
+	cheapest := astar.Dijkstra(g.GridMap, origin, dest).Cost()
+
+	paths := map[string]bool{}
+	for i, algorithm := range astar.Algorithms {
+		g.Algorithm = i
+		c.SetPath(g, px, py)
+		if c.Path == nil {
+			t.Fatalf("%s: no path to the player", algorithm.Name)
+		}
+		if algorithm.Name == "Dijkstra" {
+			// shared through the flow field, which finds a cheapest path too
+			if c.Path.Cost() != cheapest {
+				t.Errorf("%s: path costs %g, want %g", algorithm.Name, c.Path.Cost(), cheapest)
+			}
+		} else if want := g.FindPath(origin, dest); fmt.Sprint(cellsOf(c.Path)) != fmt.Sprint(cellsOf(want)) {
+			t.Errorf("%s: path %v, want %v", algorithm.Name, cellsOf(c.Path), cellsOf(want))
+		}
+		paths[fmt.Sprint(cellsOf(c.Path))] = true
+	}
+	if len(paths) < 2 {
+		t.Fatal("every algorithm chased the player the same way")
+	}
+
+	// the goal searches take the weights of the algorithm, or A*'s
+	goal := astar.NewGoalWithin(g.GridMap, dest, 1)
+	for i, algorithm := range astar.Algorithms {
+		g.Algorithm = i
+		gWeight, hWeight := algorithm.GWeight, algorithm.HWeight
+		if gWeight == 0 && hWeight == 0 {
+			gWeight, hWeight = 1, 1
+		}
+		reached := c.SetPathToGoal(g, goal)
+		s := astar.NewGoalSearch(g.GridMap, origin, goal, gWeight, hWeight, g.searchOptions())
+		if want := s.Run(); reached != s.Reached || fmt.Sprint(cellsOf(c.Path)) != fmt.Sprint(cellsOf(want)) {
+			t.Errorf("%s: goal path %v, want %v", algorithm.Name, cellsOf(c.Path), cellsOf(want))
+		}
+	}
+}
+
+func cellsOf(path *astar.Path) [][2]int {
+	cells := [][2]int{}
+	if path != nil {
+		for _, cell := range path.Cells {
+			cells = append(cells, [2]int{cell.X, cell.Y})
+		}
+	}
+	return cells
+}
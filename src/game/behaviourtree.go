@@ -0,0 +1,231 @@
+package game
+
+import (
+	"a-star/src/astar"
+	"a-star/src/bt"
+	"a-star/src/utils"
+	"fmt"
+	"path"
+
+	"github.com/lafriks/go-tiled"
+)
+
+// loadBehaviourTrees gives every chicken whose spawn point has a
+// "behaviour_tree" property the tree defined in that file under
+// assets/behaviours. Chickens with a tree ignore their behaviour state machine,
+// except for forced behaviours like the chase started by the play button.
+func loadBehaviourTrees(g *Game) {
+	for i, spawnPoint := range g.GameMap.ObjectGroups[utils.ChickenSpawnPoints].Objects {
+		tree, err := loadBehaviourTree(g, g.Chickens[i], spawnPoint)
+		if err != nil {
+			fmt.Printf("error loading behaviour tree: %s\n", err.Error())
+			continue
+		}
+		g.Chickens[i].Tree = tree
+	}
+}
+
+func loadBehaviourTree(g *Game, c *Chicken, spawnPoint *tiled.Object) (bt.Node, error) {
+	name := spawnPoint.Properties.GetString("behaviour_tree")
+	if name == "" {
+		return nil, nil
+	}
+	file, err := g.Assets.Open(path.Join(utils.BehaviourTreeDir, name))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return bt.Load(file, chickenLeaves(g, c))
+}
+
+// chickenLeaves returns the actions and conditions a tree can use to control c.
+func chickenLeaves(g *Game, c *Chicken) bt.Leaves {
+	return bt.Leaves{
+		// canSee succeeds while the player is within radius cells
+		"canSee": func(params bt.Params) (bt.Node, error) {
+			radius := params.Int("radius", c.Config.SightRadius)
+			return &bt.Condition{Check: func() bool {
+				return c.distanceToPlayer(g) <= radius
+			}}, nil
+		},
+
+		// moveTo walks to a target: the player, the spawn point, the next
+		// patrol waypoint, a random cell within radius or the cell at x, y
+		"moveTo": func(params bt.Params) (bt.Node, error) {
+			target := params.String("target", "cell")
+			radius := params.Int("radius", c.Config.WanderRadius)
+			x, y := params.Int("x", -1), params.Int("y", -1)
+			switch target {
+			case "player":
+				return chickenChase(g, c, params.Int("distance", 1)), nil
+			case "spawn", "waypoint", "random":
+			case "cell":
+				if g.GridMap.GetGridCell(x, y) == nil {
+					return nil, fmt.Errorf("moveTo: cell %d, %d is not on the map", x, y)
+				}
+			default:
+				return nil, fmt.Errorf("moveTo: unknown target %q", target)
+			}
+
+			spawnX, spawnY := c.XLoc, c.YLoc
+			return chickenMove(c, func() {
+				chickenCell := c.GetCell()
+				switch target {
+				case "spawn":
+					c.SetPathToCell(g, g.GridMap.GetGridCell(spawnX/utils.UnitSize, spawnY/utils.UnitSize))
+				case "waypoint":
+					if c.Patrol != nil {
+						c.SetPathToCell(g, c.Patrol.Next(g.Rand))
+					}
+				case "random":
+					c.wander(g, chickenCell, radius)
+				case "cell":
+					c.SetPathToCell(g, g.GridMap.GetGridCell(x, y))
+				}
+			}), nil
+		},
+
+		// flee runs to the cell within radius that is furthest from the player
+		"flee": func(params bt.Params) (bt.Node, error) {
+			radius := params.Int("radius", c.Config.FleeRadius)
+			return chickenMove(c, func() {
+				px, py := g.Player.GetCenterPoint()
+				c.flee(g, c.GetCell(), astar.GetCell(px, py), radius)
+			}), nil
+		},
+
+		// wait stands idle for a number of ticks
+		"wait": func(params bt.Params) (bt.Node, error) {
+			ticks := params.Int("ticks", c.Config.RestTime)
+			waited := 0
+			return &bt.Action{
+				Run: func() bt.Status {
+					if c.State == utils.ChickenWalkState {
+						c.State = utils.ChickenIdleState
+					}
+					waited += 1
+					if waited >= ticks {
+						waited = 0
+						return bt.Success
+					}
+					return bt.Running
+				},
+				OnReset: func() { waited = 0 },
+				Save:    func() []int { return []int{waited} },
+				Restore: func(values []int) { waited = values[0] },
+			}, nil
+		},
+	}
+}
+
+// chickenMove returns an action that plans a path with plan once the chicken
+// stands on a cell and then runs until the path has been walked. It fails if
+// plan could not find a path.
+func chickenMove(c *Chicken, plan func()) bt.Node {
+	planned := false
+	return &bt.Action{
+		Run: func() bt.Status {
+			if !planned {
+				if !c.snapToCell() {
+					return bt.Running
+				}
+				c.Path = nil
+				plan()
+				if c.Path == nil {
+					return bt.Failure
+				}
+				planned = true
+			}
+			if c.HasArrived() {
+				planned = false
+				return bt.Success
+			}
+			return bt.Running
+		},
+		OnReset: func() {
+			if planned {
+				c.Path = nil
+			}
+			planned = false
+		},
+		// the path itself is saved with the chicken
+		Save:    func() []int { return []int{boolInt(planned)} },
+		Restore: func(values []int) { planned = values[0] != 0 },
+	}
+}
+
+// chickenChase returns an action that follows the player until the chicken is
+// within distance cells of them.
+func chickenChase(g *Game, c *Chicken, distance int) bt.Node {
+	ttl, chasing := 0, false
+	return &bt.Action{
+		Run: func() bt.Status {
+			chasing = true
+			if ttl > 0 {
+				ttl -= 1
+			}
+			if !c.snapToCell() {
+				return bt.Running
+			}
+			if c.distanceToPlayer(g) <= distance {
+				c.Path = nil
+				return bt.Success
+			}
+			if c.HasArrived() || ttl == 0 {
+				// the player keeps moving, so plan again now and then
+				px, py := g.Player.GetCenterPoint()
+				c.SetPath(g, px, py)
+				ttl = utils.ChickenRepathDelay
+				if c.Path == nil {
+					return bt.Failure
+				}
+			}
+			return bt.Running
+		},
+		OnReset: func() {
+			if chasing {
+				c.Path = nil
+			}
+			ttl, chasing = 0, false
+		},
+		Save:    func() []int { return []int{ttl, boolInt(chasing)} },
+		Restore: func(values []int) { ttl, chasing = values[0], values[1] != 0 },
+	}
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// updateBehaviourTree ticks the chicken's tree, starting it over once it has
+// finished.
+func updateBehaviourTree(c *Chicken) {
+	if c.Tree.Tick() != bt.Running {
+		c.Tree.Reset()
+	}
+}
+
+// snapToCell walks the chicken onto the nearest cell if it is between cells
+// and reports whether it is standing on one.
+func (c *Chicken) snapToCell() bool {
+	if c.IsOnCell() {
+		return true
+	}
+	if c.HasArrived() {
+		c.Path = &astar.Path{Cells: []*astar.Cell{c.GetCell()}}
+	}
+	return false
+}
+
+// GetCell returns the cell under the chicken's center.
+func (c *Chicken) GetCell() *astar.Cell {
+	return astar.GetCell(c.GetCenterPoint())
+}
+
+func (c *Chicken) distanceToPlayer(g *Game) int {
+	px, py := g.Player.GetCenterPoint()
+	return int(astar.Heuristic(c.GetCell(), astar.GetCell(px, py)))
+}
@@ -0,0 +1,44 @@
+package game
+
+import (
+	"a-star/src/utils"
+	"os"
+	"testing"
+)
+
+// newTestGame loads the map called name from the assets without a window.
+func newTestGame(t *testing.T, name string) *Game {
+	t.Helper()
+	assets := os.DirFS("../../assets")
+	g := NewGame(assets, assets, []Level{{Name: name, Map: name}})
+	g.SetSeed(1)
+	return g
+}
+
+func TestPlayForcesTreeChickensToChase(t *testing.T) {
+	g := newTestGame(t, "map.tmx")
+	var c *Chicken
+	for _, chicken := range g.Chickens {
+		if chicken.Tree != nil {
+			c = chicken
+		}
+	}
+	if c == nil {
+		t.Fatal("no chicken with a behaviour tree on the map")
+	}
+
+	chaseAll(g)
+	for i := 0; i < utils.UnitSize && c.Path == nil; i++ {
+		g.tick()
+	}
+	if c.Behaviour != utils.BehaviourChase || !c.BehaviourForced {
+		t.Fatalf("behaviour %d forced %v, want a forced chase", c.Behaviour, c.BehaviourForced)
+	}
+	if c.Path == nil || len(c.Path.Cells) == 0 {
+		t.Fatal("chicken has no path to the player")
+	}
+	dest, player := c.Path.Cells[len(c.Path.Cells)-1], playerCell(g)
+	if dest.X != player.X || dest.Y != player.Y {
+		t.Errorf("path ends at %d,%d, want the player's cell %d,%d", dest.X, dest.Y, player.X, player.Y)
+	}
+}
@@ -0,0 +1,84 @@
+package game
+
+import (
+	"a-star/src/utils"
+	"image"
+	"math"
+)
+
+// Camera decides which part of the world is shown on screen. A world position
+// is scaled by Zoom and then moved by Offset to get its screen position.
+type Camera struct {
+	X      float64 // world position shown at the top left of the screen
+	Y      float64
+	Zoom   float64
+	Width  int // size of the screen in pixels
+	Height int
+}
+
+func NewCamera(width, height int) *Camera {
+	return &Camera{Zoom: 1, Width: width, Height: height}
+}
+
+// Follow centers the camera on world position x, y without showing anything
+// outside the world. A world smaller than the screen is centered instead.
+func (c *Camera) Follow(x, y float64, worldWidth, worldHeight int) {
+	viewWidth := float64(c.Width) / c.Zoom
+	viewHeight := float64(c.Height) / c.Zoom
+	c.X = clampView(x-viewWidth/2, viewWidth, float64(worldWidth))
+	c.Y = clampView(y-viewHeight/2, viewHeight, float64(worldHeight))
+}
+
+func clampView(pos, view, world float64) float64 {
+	if world <= view {
+		return (world - view) / 2
+	}
+	return math.Max(0, math.Min(pos, world-view))
+}
+
+// ZoomBy zooms in by steps, or out if steps is negative.
+func (c *Camera) ZoomBy(steps float64) {
+	c.Zoom *= math.Pow(1+utils.CameraZoomStep, steps)
+	c.Zoom = math.Max(utils.CameraMinZoom, math.Min(c.Zoom, utils.CameraMaxZoom))
+}
+
+// Offset returns where the world's origin is on screen.
+func (c *Camera) Offset() (x, y float64) {
+	// stay on whole screen pixels so tiles don't leave gaps between them
+	return math.Round(-c.X * c.Zoom), math.Round(-c.Y * c.Zoom)
+}
+
+func (c *Camera) ScreenToWorld(screenX, screenY int) (x, y int) {
+	offsetX, offsetY := c.Offset()
+	wx := (float64(screenX) - offsetX) / c.Zoom
+	wy := (float64(screenY) - offsetY) / c.Zoom
+	return int(math.Floor(wx)), int(math.Floor(wy))
+}
+
+func (c *Camera) WorldToScreen(x, y float64) (screenX, screenY int) {
+	offsetX, offsetY := c.Offset()
+	return int(math.Round(x*c.Zoom + offsetX)), int(math.Round(y*c.Zoom + offsetY))
+}
+
+// VisibleTiles returns the range of tiles, of the given size, that are at
+// least partly on screen, clipped to a map of width by height tiles.
+func (c *Camera) VisibleTiles(tileWidth, tileHeight, width, height int) image.Rectangle {
+	minX, minY := c.ScreenToWorld(0, 0)
+	maxX, maxY := c.ScreenToWorld(c.Width, c.Height)
+	view := image.Rect(floorDiv(minX, tileWidth), floorDiv(minY, tileHeight),
+		floorDiv(maxX, tileWidth)+1, floorDiv(maxY, tileHeight)+1)
+	return view.Intersect(image.Rect(0, 0, width, height))
+}
+
+func floorDiv(a, b int) int {
+	return int(math.Floor(float64(a) / float64(b)))
+}
+
+func updateCamera(g *Game) {
+	if _, wheelY := g.Input.Wheel(); wheelY != 0 {
+		g.Camera.ZoomBy(wheelY)
+	}
+	px, py := g.Player.GetCenterPoint()
+	g.Camera.Follow(float64(px), float64(py),
+		g.GameMap.Width*g.GameMap.TileWidth, g.GameMap.Height*g.GameMap.TileHeight)
+}
@@ -0,0 +1,107 @@
+package game
+
+import (
+	"a-star/src/utils"
+	"math"
+	"testing"
+)
+
+func TestCameraRoundTrip(t *testing.T) {
+	tests := []struct {
+		zoom float64
+		x, y float64
+	}{
+		{1, 0, 0},
+		{1, 37, 120},
+		{2, 100, 50},
+		{3, 13.4, 7.9},
+		{1.1, 0, 0},
+		{1.7, 221.3, 48.6},
+		{utils.CameraMinZoom, 64, 96},
+	}
+	for _, test := range tests {
+		c := &Camera{X: test.x, Y: test.y, Zoom: test.zoom, Width: 320, Height: 240}
+
+		// a screen pixel goes back to the screen pixel where its world
+		// pixel starts, which is at most one world pixel away
+		for sx := -3; sx < 40; sx++ {
+			sy := sx*3 + 1
+			wx, wy := c.ScreenToWorld(sx, sy)
+			bx, by := c.WorldToScreen(float64(wx), float64(wy))
+			if math.Abs(float64(bx-sx)) > math.Ceil(test.zoom) || math.Abs(float64(by-sy)) > math.Ceil(test.zoom) {
+				t.Errorf("zoom %g at %g,%g: screen %d,%d is world %d,%d, back at %d,%d", test.zoom, test.x, test.y, sx, sy, wx, wy, bx, by)
+			}
+		}
+
+		// a world pixel comes back where it was, exactly at whole zooms
+		for wx := int(test.x); wx < int(test.x)+40; wx++ {
+			wy := wx/2 + 3
+			sx, sy := c.WorldToScreen(float64(wx), float64(wy))
+			bx, by := c.ScreenToWorld(sx, sy)
+			off := max(abs(bx-wx), abs(by-wy))
+			if test.zoom < 1 {
+				// several world pixels share a screen pixel
+				if off > int(math.Ceil(1/test.zoom)) {
+					t.Errorf("zoom %g: world %d,%d back at %d,%d", test.zoom, wx, wy, bx, by)
+				}
+			} else if off > 1 || (test.zoom == math.Trunc(test.zoom) && off != 0) {
+				t.Errorf("zoom %g: world %d,%d back at %d,%d", test.zoom, wx, wy, bx, by)
+			}
+		}
+	}
+}
+
+func abs(x int) int {
+	return max(x, -x)
+}
+
+func TestCameraFollowClampsToTheMap(t *testing.T) {
+	const worldWidth, worldHeight = 960, 640
+	tests := []struct {
+		name       string
+		zoom       float64
+		x, y       float64
+		wantX      float64
+		wantY      float64
+		wantOrigin bool // the top left corner of the world is at the top left of the screen
+	}{
+		{"centered", 1, 480, 320, 320, 200, false},
+		{"top left corner", 1, 10, 5, 0, 0, true},
+		{"bottom right corner", 1, 950, 630, 960 - 320, 640 - 240, false},
+		{"zoomed top left", 2, 30, 30, 0, 0, true},
+		{"zoomed bottom right", 2, 960, 640, 960 - 160, 640 - 120, false},
+		{"zoomed out past the map", 0.25, 10, 10, (960 - 1280) / 2, (640 - 960) / 2, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := &Camera{Zoom: test.zoom, Width: 320, Height: 240}
+			c.Follow(test.x, test.y, worldWidth, worldHeight)
+			if c.X != test.wantX || c.Y != test.wantY {
+				t.Fatalf("camera at %g,%g, want %g,%g", c.X, c.Y, test.wantX, test.wantY)
+			}
+			if x, y := c.ScreenToWorld(0, 0); test.wantOrigin && (x != 0 || y != 0) {
+				t.Fatalf("top left of the screen shows world %d,%d", x, y)
+			}
+
+			// with the world bigger than the view, the screen shows nothing
+			// outside it
+			if float64(worldWidth)*test.zoom < float64(c.Width) {
+				return
+			}
+			minX, minY := c.ScreenToWorld(0, 0)
+			maxX, maxY := c.ScreenToWorld(c.Width-1, c.Height-1)
+			if minX < 0 || minY < 0 || maxX >= worldWidth || maxY >= worldHeight {
+				t.Fatalf("screen shows world %d,%d to %d,%d", minX, minY, maxX, maxY)
+			}
+		})
+	}
+}
+
+func TestCameraVisibleTiles(t *testing.T) {
+	c := &Camera{X: 40, Y: -8, Zoom: 2, Width: 320, Height: 240}
+	got := c.VisibleTiles(32, 32, 30, 20)
+	// world 40,-8 to 200,112: columns 1 to 6, rows -1 clipped to 0 to 3
+	if got.Min.X != 1 || got.Min.Y != 0 || got.Max.X != 7 || got.Max.Y != 4 {
+		t.Fatalf("visible tiles %v", got)
+	}
+}
@@ -0,0 +1,76 @@
+package game
+
+import (
+	"a-star/src/capture"
+	"a-star/src/utils"
+	"fmt"
+	"image"
+	"image/color"
+)
+
+var (
+	capturedPathColor    = color.NRGBA{0xe0, 0xc0, 0x20, 0x90}
+	capturedChickenColor = color.NRGBA{0xf0, 0xe0, 0x40, 0xff}
+	capturedPlayerColor  = color.NRGBA{0x20, 0x60, 0xe0, 0xff}
+)
+
+// RenderGrid draws the game on its grid without a window, so it also works
+// in headless runs: the grid map, with fences drawn as walls, the search view,
+// what is left of each chicken's path, and a marker for everyone.
+func RenderGrid(g *Game) *image.RGBA {
+	size := utils.CaptureCellSize
+	var img *image.RGBA
+	if g.SearchView != nil {
+		img = capture.Search(g.SearchView.Search, size)
+	} else {
+		img = capture.Grid(g.GridMap, size)
+	}
+
+	for _, c := range g.Chickens {
+		if c.Path == nil {
+			continue
+		}
+		for i := c.Path.CurrentCell; i < len(c.Path.Cells); i++ {
+			capture.FillCell(img, c.Path.Cells[i], size, capturedPathColor)
+		}
+	}
+	for _, c := range g.Chickens {
+		capture.Marker(img, c.GetCell(), size, capturedChickenColor)
+	}
+	capture.Marker(img, playerCell(g), size, capturedPlayerColor)
+	return img
+}
+
+// toggleCapture starts capturing the screen, or stops and saves the capture.
+func toggleCapture(g *Game) {
+	if g.Capture == nil {
+		if replaying(g, "capturing the screen") {
+			return
+		}
+		g.Capture = capture.New(utils.CaptureEvery, utils.CaptureMaxFrames, 100*utils.CaptureEvery/utils.TicksPerSecond)
+		fmt.Println("capturing the screen, press G again to stop")
+		return
+	}
+	saveCapture(g)
+}
+
+func saveCapture(g *Game) {
+	if err := g.Capture.Save(utils.CapturePath); err != nil {
+		fmt.Printf("error saving capture: %s\n", err.Error())
+	} else {
+		fmt.Printf("saved %d frames to %s\n", g.Capture.Len(), utils.CapturePath)
+	}
+	g.Capture = nil
+}
+
+// CaptureScreen is called by the view once the screen is drawn, with a
+// function reading it back. The capture is saved once full.
+func (g *Game) CaptureScreen(render func() image.Image) {
+	if g.Capture == nil {
+		return
+	}
+	g.Capture.Frame(render)
+	if g.Capture.Full() {
+		saveCapture(g)
+	}
+}
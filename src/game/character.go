@@ -2,47 +2,52 @@ package game
 
 import (
 	"a-star/src/astar"
+	"a-star/src/bt"
 	"a-star/src/utils"
-	"embed"
 
-	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/lafriks/go-tiled"
 )
 
 type Player struct {
-	SpriteSheet *ebiten.Image
-	XLoc        int
-	YLoc        int
-	Dx          int
-	Dy          int
-	State       int
-	StateTTL    int
-	Direction   int
-	Frame       int
-	Sprite      CollisionBody
-	Collision   CollisionBody
+	XLoc      int
+	YLoc      int
+	Dx        int
+	Dy        int
+	State     int
+	StateTTL  int
+	Direction int
+	Frame     int
+	Sprite    CollisionBody
+	Collision CollisionBody
 }
 
 type Chicken struct {
-	SpriteSheet *ebiten.Image
-	XLoc        int
-	YLoc        int
-	Dx          int
-	Dy          int
-	State       int
-	StateTTL    int
-	Direction   int
-	Frame       int
-	Sprite      CollisionBody
-	Collision   CollisionBody
-	Path        *astar.Path
-}
-
-func NewPlayer(embeddedAssets embed.FS, x, y int) *Player {
+	XLoc      int
+	YLoc      int
+	Dx        int
+	Dy        int
+	State     int
+	StateTTL  int
+	Direction int
+	Frame     int
+	Sprite    CollisionBody
+	Collision CollisionBody
+	Path      *astar.Path
+	Patrol    *PatrolRoute
+	SteerX    int // offset from the path added by steering
+	SteerY    int
+
+	Behaviour       int
+	BehaviourTTL    int
+	BehaviourForced bool
+	Config          BehaviourConfig
+	Tree            bt.Node // overrides the behaviour state machine when set
+}
+
+func NewPlayer(x, y int) *Player {
 	return &Player{
-		SpriteSheet: loadImage(embeddedAssets, "assets/player.png"),
-		XLoc:        x - 39,
-		YLoc:        y - 35,
+		XLoc: x - 39,
+		YLoc: y - 35,
 		Sprite: CollisionBody{
 			X:      x,
 			Y:      y,
@@ -58,16 +63,14 @@ func NewPlayer(embeddedAssets embed.FS, x, y int) *Player {
 	}
 }
 
-func NewChickens(embeddedAssets embed.FS, gameMap *tiled.Map) []*Chicken {
+func NewChickens(gameMap *tiled.Map, routes map[string][]*astar.Cell) []*Chicken {
 	chickens := []*Chicken{}
-	spritesheet := loadImage(embeddedAssets, "assets/chicken.png")
 	for _, spawnPoint := range gameMap.ObjectGroups[utils.ChickenSpawnPoints].Objects {
 		xLoc := int(spawnPoint.X)
 		yLoc := int(spawnPoint.Y)
 		chicken := &Chicken{
-			SpriteSheet: spritesheet,
-			XLoc:        xLoc,
-			YLoc:        yLoc,
+			XLoc: xLoc,
+			YLoc: yLoc,
 			Sprite: CollisionBody{
 				X:      xLoc,
 				Y:      yLoc,
@@ -80,19 +83,52 @@ func NewChickens(embeddedAssets embed.FS, gameMap *tiled.Map) []*Chicken {
 				Width:  16,
 				Height: 14,
 			},
+			Patrol: NewPatrolRoute(spawnPoint, routes),
+			Config: NewBehaviourConfig(spawnPoint),
 		}
 		chickens = append(chickens, chicken)
 	}
 	return chickens
 }
 
+// SetPath sends the chicken to pixel x, y, where the player is, with the search
+// picked in the controls.
 func (c *Chicken) SetPath(g *Game, x, y int) {
 	cx, cy := c.GetCenterPoint()
-	px, py := g.Player.GetCenterPoint()
 	originCell := astar.GetCell(cx, cy)
-	destCell := astar.GetCell(px, py)
+	destCell := astar.GetCell(x, y)
+
+	// the flow field is a search by cost alone from the player, shared by every
+	// chicken chasing them, so it stands in for a search per chicken with
+	// Dijkstra
+	if algorithm := astar.Algorithms[g.Algorithm]; algorithm.GWeight == 1 && algorithm.HWeight == 0 {
+		g.FlowField.SetGoal(destCell)
+		c.Path = g.FlowField.PathFrom(originCell)
+		return
+	}
+	c.Path = g.FindPath(originCell, destCell)
+}
+
+// SetPathToCell sends the chicken to cell using the search picked in the
+// controls.
+func (c *Chicken) SetPathToCell(g *Game, cell *astar.Cell) {
+	cx, cy := c.GetCenterPoint()
+	c.Path = g.FindPath(astar.GetCell(cx, cy), cell)
+}
 
-	c.Path = astar.AStar(g.GridMap, originCell, destCell)
+// SetPathToGoal sends the chicken to the nearest cell of goal, e.g. any cell
+// within a few tiles of the player, and returns the cell it will end up in.
+// It searches with the weights of the algorithm picked in the controls, or
+// with A* for the algorithms that can't search for a set of cells.
+func (c *Chicken) SetPathToGoal(g *Game, goal astar.Goal) *astar.Cell {
+	cx, cy := c.GetCenterPoint()
+	algorithm := astar.Algorithms[g.Algorithm]
+	if algorithm.GWeight == 0 && algorithm.HWeight == 0 {
+		algorithm = astar.Algorithms[0]
+	}
+	s := astar.NewGoalSearch(g.GridMap, astar.GetCell(cx, cy), goal, algorithm.GWeight, algorithm.HWeight, g.searchOptions())
+	c.Path = s.Run()
+	return s.Reached
 }
 
 func (p *Player) UpdateFrame(currentFrame int) {
@@ -127,6 +163,61 @@ func (c *Chicken) UpdateFrame(currentFrame int) {
 	}
 }
 
+// FollowPath moves the chicken one step towards the next cell of its path.
+func (c *Chicken) FollowPath() {
+	if c.Path == nil {
+		return
+	}
+
+	nextCell := c.Path.GetCurrentCell()
+	if nextCell == nil {
+		// already at destination
+		if c.State == utils.ChickenWalkState {
+			c.State = utils.ChickenIdleState
+		}
+		return
+	}
+
+	if nextCell.X*32 == c.XLoc && nextCell.Y*32 == c.YLoc {
+		c.Path.Next()
+		return
+	}
+
+	if nextCell.X*32 < c.XLoc {
+		// walk left
+		c.Direction = utils.ChickenLeft
+		c.State = utils.ChickenWalkState
+		c.Dx -= utils.ChickenMovementSpeed
+		c.UpdateLocation()
+	} else if nextCell.X*32 > c.XLoc {
+		// walk right
+		c.Direction = utils.ChickenRight
+		c.State = utils.ChickenWalkState
+		c.Dx += utils.ChickenMovementSpeed
+		c.UpdateLocation()
+	} else if nextCell.Y*32 < c.YLoc {
+		// walk down
+		c.State = utils.ChickenWalkState
+		c.Dy -= utils.ChickenMovementSpeed
+		c.UpdateLocation()
+	} else if nextCell.Y*32 > c.YLoc {
+		// walk up
+		c.State = utils.ChickenWalkState
+		c.Dy += utils.ChickenMovementSpeed
+		c.UpdateLocation()
+	}
+}
+
+// IsOnCell reports whether the chicken stands exactly on a grid cell.
+func (c *Chicken) IsOnCell() bool {
+	return c.XLoc%utils.UnitSize == 0 && c.YLoc%utils.UnitSize == 0
+}
+
+// HasArrived reports whether the chicken has no path left to walk.
+func (c *Chicken) HasArrived() bool {
+	return c.Path == nil || c.Path.GetCurrentCell() == nil
+}
+
 func (p *Player) UpdateLocation() {
 	p.XLoc += p.Dx
 	p.YLoc += p.Dy
@@ -182,8 +273,10 @@ func (c *Chicken) Restart(g *Game, x, y int) {
 	c.YLoc = y
 	c.Sprite.X = x
 	c.Sprite.Y = y
-	c.Collision.X = x
-	c.Collision.Y = y
+	c.Collision.X = x + 8
+	c.Collision.Y = y + 16
+	c.SteerX = 0
+	c.SteerY = 0
 
 	c.Dx = 0
 	c.Dy = 0
@@ -192,6 +285,13 @@ func (c *Chicken) Restart(g *Game, x, y int) {
 	c.Direction = 0
 	c.Frame = 0
 	c.Path = nil
+	if c.Patrol != nil {
+		c.Patrol.Restart()
+	}
+	c.SetBehaviour(utils.BehaviourRest, false)
+	if c.Tree != nil {
+		c.Tree.Reset()
+	}
 }
 
 type CollisionBody struct {
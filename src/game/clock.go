@@ -0,0 +1,37 @@
+package game
+
+import "a-star/src/utils"
+
+// Clock decides how many simulation ticks run for each frame Ebiten updates.
+// Everything that moves or animates counts ticks, not frames, so pausing or
+// fast-forwarding the clock affects all of it the same way.
+type Clock struct {
+	Tick   int // ticks simulated since the game started
+	Paused bool
+	Speed  int // index in utils.SimulationSpeeds
+	step   bool
+}
+
+// Step runs a single tick on the next frame while the clock is paused.
+func (c *Clock) Step() {
+	c.step = true
+}
+
+// Ticks returns how many ticks to simulate this frame.
+func (c *Clock) Ticks() int {
+	if c.Paused {
+		if c.step {
+			c.step = false
+			return 1
+		}
+		return 0
+	}
+	c.step = false
+	return utils.SimulationSpeeds[c.Speed]
+}
+
+// Millis returns the time the simulation has run for, in milliseconds at
+// normal speed.
+func (c *Clock) Millis() int {
+	return c.Tick * 1000 / utils.TicksPerSecond
+}
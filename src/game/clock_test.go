@@ -0,0 +1,76 @@
+package game
+
+import (
+	"a-star/src/utils"
+	"testing"
+)
+
+func TestClockTicks(t *testing.T) {
+	c := Clock{}
+	for speed, want := range utils.SimulationSpeeds {
+		c.Speed = speed
+		if got := c.Ticks(); got != want {
+			t.Errorf("speed %d runs %d ticks a frame, want %d", speed, got, want)
+		}
+	}
+
+	// paused, only a step runs, and only once
+	c.Paused = true
+	if got := c.Ticks(); got != 0 {
+		t.Fatalf("paused clock runs %d ticks", got)
+	}
+	c.Step()
+	if got := c.Ticks(); got != 1 {
+		t.Fatalf("step runs %d ticks, want 1", got)
+	}
+	if got := c.Ticks(); got != 0 {
+		t.Fatalf("step ran again, %d ticks", got)
+	}
+
+	// a step while running is forgotten rather than kept for the next pause
+	c.Paused = false
+	c.Step()
+	c.Ticks()
+	c.Paused = true
+	if got := c.Ticks(); got != 0 {
+		t.Fatalf("step taken while running ran %d ticks once paused", got)
+	}
+}
+
+func TestClockMillis(t *testing.T) {
+	c := Clock{Tick: utils.TicksPerSecond * 3 / 2}
+	if got := c.Millis(); got != 1500 {
+		t.Fatalf("%d ticks are %d ms, want 1500", c.Tick, got)
+	}
+}
+
+func TestPauseStepAndSpeedUp(t *testing.T) {
+	g := newTestGame(t, "map.tmx")
+	c := freeChicken(t, g)
+
+	// P pauses, and nothing moves while paused
+	runTestGame(t, g, "1 press P\n2 release P\n", 1)
+	tick, x, y := g.Clock.Tick, c.XLoc, c.YLoc
+	if !g.Clock.Paused {
+		t.Fatal("P didn't pause the game")
+	}
+	runTestGame(t, g, "", 20)
+	if g.Clock.Tick != tick || c.XLoc != x || c.YLoc != y {
+		t.Fatalf("paused game ran to tick %d", g.Clock.Tick)
+	}
+
+	// N runs one tick per press
+	runTestGame(t, g, "1 press N\n2 release N\n3 press N\n4 release N\n", 5)
+	if g.Clock.Tick != tick+2 {
+		t.Fatalf("2 steps ran %d ticks", g.Clock.Tick-tick)
+	}
+
+	// unpaused at the fastest speed, a frame runs that many ticks
+	g.Clock.Paused = false
+	g.Clock.Speed = len(utils.SimulationSpeeds) - 1
+	tick = g.Clock.Tick
+	runTestGame(t, g, "", 10)
+	if want := tick + 10*utils.SimulationSpeeds[g.Clock.Speed]; g.Clock.Tick != want {
+		t.Fatalf("fast game at tick %d, want %d", g.Clock.Tick, want)
+	}
+}
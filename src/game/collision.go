@@ -2,8 +2,6 @@ package game
 
 import (
 	"a-star/src/utils"
-
-	"github.com/co0p/tankism/lib/collision"
 )
 
 func playerHasCollisions(g *Game, player *Player) bool {
@@ -14,47 +12,45 @@ func playerHasCollisions(g *Game, player *Player) bool {
 }
 
 func hasMapCollisions(g *Game, dx, dy int, collisionBody CollisionBody) bool {
-	for tileY := 0; tileY < g.GameMap.Height; tileY += 1 {
-		for tileX := 0; tileX < g.GameMap.Width; tileX += 1 {
-			for _, layer := range utils.CollisionLayers {
-				tile := g.GameMap.Layers[layer].Tiles[tileY*g.GameMap.Width+tileX]
-				if tile.IsNil() {
-					continue
-				}
-				tileXpos := g.GameMap.TileWidth * tileX
-				tileYpos := g.GameMap.TileHeight * tileY
+	// only the tiles under the moved body can collide with it, so the cost of a
+	// check doesn't depend on the size of the map
+	minX := max(floorDiv(collisionBody.X+dx, g.GameMap.TileWidth), 0)
+	minY := max(floorDiv(collisionBody.Y+dy, g.GameMap.TileHeight), 0)
+	maxX := min(floorDiv(collisionBody.X+dx+collisionBody.Width, g.GameMap.TileWidth), g.GameMap.Width-1)
+	maxY := min(floorDiv(collisionBody.Y+dy+collisionBody.Height, g.GameMap.TileHeight), g.GameMap.Height-1)
 
-				tileCollision := CollisionBody{
-					X:      tileXpos,
-					Y:      tileYpos,
-					Width:  g.GameMap.TileWidth,
-					Height: g.GameMap.TileHeight,
-				}
-				if hasCollision(dx, dy, collisionBody, tileCollision) {
+	for tileY := minY; tileY <= maxY; tileY += 1 {
+		for tileX := minX; tileX <= maxX; tileX += 1 {
+			tileXpos := g.GameMap.TileWidth * tileX
+			tileYpos := g.GameMap.TileHeight * tileY
+
+			tileCollision := CollisionBody{
+				X:      tileXpos,
+				Y:      tileYpos,
+				Width:  g.GameMap.TileWidth,
+				Height: g.GameMap.TileHeight,
+			}
+			if !hasCollision(dx, dy, collisionBody, tileCollision) {
+				continue
+			}
+			for _, layer := range utils.CollisionLayers {
+				if !g.GameMap.Layers[layer].Tiles[tileY*g.GameMap.Width+tileX].IsNil() {
 					return true
 				}
 			}
+			if _, ok := g.Fences[g.GridMap.GetGridCell(tileX, tileY)]; ok {
+				return true
+			}
 		}
 	}
 	return false
 }
 
 func hasCollision(dx, dy int, bodyA, bodyB CollisionBody) bool {
-	// check if movement of bodyA collides with bodyB
-	aBounds := collision.BoundingBox{
-		X:      float64(bodyA.X + dx),
-		Y:      float64(bodyA.Y + dy),
-		Width:  float64(bodyA.Width),
-		Height: float64(bodyA.Height),
-	}
-	bBounds := collision.BoundingBox{
-		X:      float64(bodyB.X),
-		Y:      float64(bodyB.Y),
-		Width:  float64(bodyB.Width),
-		Height: float64(bodyB.Height),
-	}
-	if collision.AABBCollision(aBounds, bBounds) {
-		return true
-	}
-	return false
+	// check if movement of bodyA collides with bodyB, bodies that only touch
+	// don't collide
+	return bodyA.X+dx < bodyB.X+bodyB.Width &&
+		bodyA.X+dx+bodyA.Width > bodyB.X &&
+		bodyA.Y+dy < bodyB.Y+bodyB.Height &&
+		bodyA.Y+dy+bodyA.Height > bodyB.Y
 }
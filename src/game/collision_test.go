@@ -0,0 +1,92 @@
+package game
+
+import (
+	"a-star/src/astar"
+	"a-star/src/utils"
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/lafriks/go-tiled"
+)
+
+// newCollisionGame returns a game on a size by size map with a wall on every
+// fourth tile, which is all hasMapCollisions needs.
+func newCollisionGame(size int) *Game {
+	gameMap := &tiled.Map{
+		Width:      size,
+		Height:     size,
+		TileWidth:  utils.UnitSize,
+		TileHeight: utils.UnitSize,
+	}
+	for layer := 0; layer <= utils.CollisionLayer; layer++ {
+		tiles := []*tiled.LayerTile{}
+		for i := 0; i < size*size; i++ {
+			wall := layer == utils.CollisionLayer && i%4 == 0
+			tiles = append(tiles, &tiled.LayerTile{Nil: !wall})
+		}
+		gameMap.Layers = append(gameMap.Layers, &tiled.Layer{Tiles: tiles})
+	}
+
+	return &Game{
+		GameMap: gameMap,
+		GridMap: astar.NewGridMap(gameMap),
+		Fences:  map[*astar.Cell]*astar.Obstacle{},
+	}
+}
+
+func TestHasMapCollisions(t *testing.T) {
+	g := newCollisionGame(8)
+	// walls are on every fourth tile: 0,0, 4,0, 0,1, 4,1 and so on
+	tests := []struct {
+		name   string
+		body   CollisionBody
+		dx, dy int
+		want   bool
+	}{
+		{"on a wall", CollisionBody{X: 4, Y: 4, Width: 18, Height: 16}, 0, 0, true},
+		{"between walls", CollisionBody{X: 40, Y: 8, Width: 18, Height: 16}, 0, 0, false},
+		{"moving into a wall", CollisionBody{X: 40, Y: 8, Width: 18, Height: 16}, -10, 0, true},
+		{"touching a wall", CollisionBody{X: 32, Y: 8, Width: 18, Height: 16}, 0, 0, false},
+		{"moving off the map", CollisionBody{X: 100, Y: 200, Width: 18, Height: 16}, 0, 100, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := hasMapCollisions(g, test.dx, test.dy, test.body); got != test.want {
+				t.Errorf("hasMapCollisions = %v, want %v", got, test.want)
+			}
+		})
+	}
+
+	fenced := g.GridMap.GetGridCell(2, 0)
+	g.Fences[fenced] = &astar.Obstacle{Cells: []*astar.Cell{fenced}}
+	if !hasMapCollisions(g, 0, 0, CollisionBody{X: 70, Y: 8, Width: 18, Height: 16}) {
+		t.Error("no collision with a fence")
+	}
+}
+
+// BenchmarkHasMapCollisions checks random bodies on growing maps. Checks only
+// look at the tiles under the body, so the time per check should stay the
+// same however big the map gets.
+func BenchmarkHasMapCollisions(b *testing.B) {
+	for _, size := range []int{30, 100, 200, 500} {
+		b.Run(fmt.Sprintf("%dx%d", size, size), func(b *testing.B) {
+			g := newCollisionGame(size)
+			rng := rand.New(rand.NewSource(1))
+			bodies := []CollisionBody{}
+			for i := 0; i < 1024; i++ {
+				bodies = append(bodies, CollisionBody{
+					X:      rng.Intn(size * utils.UnitSize),
+					Y:      rng.Intn(size * utils.UnitSize),
+					Width:  18,
+					Height: 16,
+				})
+			}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				hasMapCollisions(g, utils.PlayerMovementSpeed, 0, bodies[i%len(bodies)])
+			}
+		})
+	}
+}
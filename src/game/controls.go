@@ -0,0 +1,91 @@
+package game
+
+import (
+	"a-star/src/astar"
+	"a-star/src/input"
+	"a-star/src/ui"
+	"a-star/src/utils"
+	"fmt"
+)
+
+// newControls lays out the widgets drawn over the game. They are created again
+// for every level, since the brushes depend on the map.
+func newControls(g *Game) *ui.UI {
+	algorithms := []string{}
+	for _, algorithm := range astar.Algorithms {
+		algorithms = append(algorithms, algorithm.Name)
+	}
+	heuristics := []string{}
+	for _, heuristic := range astar.Heuristics {
+		heuristics = append(heuristics, heuristic.Name)
+	}
+	speeds := []string{}
+	for _, speed := range utils.SimulationSpeeds {
+		speeds = append(speeds, fmt.Sprintf("%dx", speed))
+	}
+	brushes := []string{}
+	for _, brush := range g.Editor.Brushes {
+		brushes = append(brushes, brush.Name)
+	}
+
+	return ui.New(ui.Column(0, 0, 4,
+		&ui.Button{Image: g.assetImage("play.png"), OnClick: func() { chaseAll(g) }},
+		&ui.Button{Image: g.assetImage("restart.png"), OnClick: func() { restart(g) }},
+		&ui.Dropdown{Text: "search", Options: algorithms, Selected: &g.Algorithm},
+		&ui.Dropdown{Text: "heuristic", Options: heuristics, Selected: &g.Heuristic},
+		&ui.Toggle{Text: "pause [P]", Value: &g.Clock.Paused, Keys: []input.Key{input.KeyP}},
+		&ui.Button{Text: "step [N]", Keys: []input.Key{input.KeyN}, OnClick: g.Clock.Step},
+		&ui.Dropdown{Text: "speed", Options: speeds, Selected: &g.Clock.Speed},
+		&ui.Label{TextFunc: func() string { return clockStatus(g) }},
+		&ui.Toggle{Text: "edit map [E]", Value: &g.Editor.Enabled, Keys: []input.Key{input.KeyE}},
+		&ui.Dropdown{Text: "brush [C]", Options: brushes, Selected: &g.Editor.Brush, Keys: []input.Key{input.KeyC}},
+		&ui.Button{Text: "save map [ctrl+S]", OnClick: func() { saveEdits(g) }},
+		&ui.Button{Text: "save game [F5]", Keys: []input.Key{input.KeyF5}, OnClick: func() { saveGame(g) }},
+		&ui.Button{Text: "load game [F9]", Keys: []input.Key{input.KeyF9}, OnClick: func() { loadGame(g) }},
+	)...)
+}
+
+// chaseAll sends every chicken onto the player's cell, following them around
+// until the game is restarted.
+func chaseAll(g *Game) {
+	for _, c := range g.Chickens {
+		c.SetBehaviour(utils.BehaviourChase, true)
+	}
+}
+
+func restart(g *Game) {
+	g.Player.Restart(g)
+	for i, spawnPoint := range g.GameMap.ObjectGroups[utils.ChickenSpawnPoints].Objects {
+		g.Chickens[i].Restart(g, int(spawnPoint.X), int(spawnPoint.Y))
+	}
+}
+
+func clockStatus(g *Game) string {
+	status := fmt.Sprintf("tick %d", g.Clock.Tick)
+	if g.Clock.Paused {
+		status += " (paused)"
+	}
+	if g.SearchView != nil {
+		status += "  " + g.SearchView.Status()
+	}
+	return status
+}
+
+// searchOptions returns the options for the heuristic picked in the controls.
+func (g *Game) searchOptions() astar.SearchOptions {
+	options := astar.DefaultSearchOptions
+	options.Heuristic = astar.Heuristics[g.Heuristic].Func
+	return options
+}
+
+// FindPath looks for a path with the search algorithm and heuristic picked in
+// the controls. A* stands in for algorithms that can't search the map, e.g.
+// JPS once fences or terrain make costs differ.
+func (g *Game) FindPath(originCell, destCell *astar.Cell) *astar.Path {
+	algorithm := astar.Algorithms[g.Algorithm]
+	if !algorithm.Searches(g.GridMap) {
+		algorithm = astar.Algorithms[0]
+	}
+	path, _ := algorithm.Search(g.GridMap, originCell, destCell, g.searchOptions())
+	return path
+}
@@ -0,0 +1,339 @@
+package game
+
+import (
+	"a-star/src/astar"
+	"a-star/src/input"
+	"a-star/src/utils"
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/lafriks/go-tiled"
+)
+
+// Editor lets walls and terrain be painted onto the map while the game runs.
+// Left-drag paints with the current brush, right-drag erases.
+type Editor struct {
+	Enabled  bool
+	Brush    int
+	Brushes  []*Brush
+	SavePath string // file the edited map is saved to, next to the map if empty
+	eraser   *Brush
+}
+
+// Brush paints either a wall or a ground tile with a terrain cost.
+type Brush struct {
+	Name string
+	Wall bool
+	Tile *tiled.LayerTile
+	Cost float64
+}
+
+// NewEditor creates a wall brush painting wallTile, unless it is nil, and a
+// terrain brush for every tile with a "cost" property, cheapest first. The
+// cheapest terrain is also what the eraser leaves behind.
+func NewEditor(gameMap *tiled.Map, wallTile *tiled.LayerTile) *Editor {
+	e := &Editor{}
+	if wallTile != nil {
+		e.Brushes = append(e.Brushes, &Brush{Name: "wall", Wall: true, Tile: wallTile})
+	}
+	terrains := []*Brush{}
+	for _, tileset := range gameMap.Tilesets {
+		for _, tile := range tileset.Tiles {
+			layerTile := &tiled.LayerTile{ID: tile.ID, Tileset: tileset}
+			if tile.Properties.GetFloat("cost") > 0 {
+				cost := astar.TileCost(layerTile)
+				terrains = append(terrains, &Brush{
+					Name: "cost " + strconv.FormatFloat(cost, 'f', -1, 64),
+					Tile: layerTile,
+					Cost: cost,
+				})
+			}
+		}
+	}
+	sort.SliceStable(terrains, func(i, j int) bool { return terrains[i].Cost < terrains[j].Cost })
+	if len(terrains) > 0 {
+		e.eraser = terrains[0]
+	}
+	e.Brushes = append(e.Brushes, terrains...)
+	return e
+}
+
+func updateEditor(g *Game) {
+	e := g.Editor
+	if !e.Enabled {
+		return
+	}
+
+	if g.Input.IsKeyPressed(input.KeyControl) && g.Input.IsKeyJustPressed(input.KeyS) {
+		saveEdits(g)
+	}
+	// clicks on the controls don't paint
+	if g.Controls.HasMouse() {
+		return
+	}
+
+	mouseX, mouseY := g.Camera.ScreenToWorld(g.Input.CursorPosition())
+	cellX, cellY := floorDiv(mouseX, g.GameMap.TileWidth), floorDiv(mouseY, g.GameMap.TileHeight)
+	if g.Input.IsMouseButtonPressed(input.MouseButtonLeft) && len(e.Brushes) > 0 {
+		paintCell(g, cellX, cellY, e.Brushes[e.Brush])
+	} else if g.Input.IsMouseButtonPressed(input.MouseButtonRight) {
+		eraseCell(g, cellX, cellY)
+	}
+}
+
+// paintCell paints brush onto the cell at x, y, updating both the tiles drawn
+// and the grid map.
+func paintCell(g *Game, x, y int, brush *Brush) {
+	cell := g.GridMap.GetGridCell(x, y)
+	if cell == nil {
+		return
+	}
+	i := y*g.GameMap.Width + x
+	ground := g.GameMap.Layers[utils.GroundLayer].Tiles
+	walls := g.GameMap.Layers[utils.CollisionLayer].Tiles
+
+	if brush.Wall {
+		if isCellOccupied(g, cell) {
+			return
+		}
+		if fence, ok := g.Fences[cell]; ok {
+			// the wall replaces the fence
+			delete(g.Fences, cell)
+			g.GridMap.RemoveObstacle(fence)
+		}
+		walls[i] = copyTile(brush.Tile)
+	} else {
+		ground[i] = copyTile(brush.Tile)
+	}
+	g.GridMap.SetCell(x, y, walls[i].IsNil(), astar.TileCost(ground[i]))
+	tilesChanged(g, x, y)
+}
+
+// eraseCell takes away the wall on the cell at x, y and resets its terrain.
+func eraseCell(g *Game, x, y int) {
+	if g.GridMap.GetGridCell(x, y) == nil {
+		return
+	}
+	i := y*g.GameMap.Width + x
+	g.GameMap.Layers[utils.CollisionLayer].Tiles[i] = &tiled.LayerTile{Nil: true}
+	if g.Editor.eraser != nil {
+		g.GameMap.Layers[utils.GroundLayer].Tiles[i] = copyTile(g.Editor.eraser.Tile)
+	}
+	g.GridMap.SetCell(x, y, true, astar.TileCost(g.GameMap.Layers[utils.GroundLayer].Tiles[i]))
+	tilesChanged(g, x, y)
+}
+
+// tilesChanged lets the view know the tiles of the cell at x, y have changed.
+func tilesChanged(g *Game, x, y int) {
+	if g.View != nil {
+		g.View.TilesChanged(x, y)
+	}
+}
+
+func saveEdits(g *Game) {
+	if replaying(g, "saving the map") {
+		return
+	}
+	name := g.Editor.SavePath
+	if name == "" {
+		name = editedMapPath(g)
+	}
+	if err := saveMap(g, name); err != nil {
+		fmt.Printf("error saving map: %s\n", err.Error())
+	} else {
+		fmt.Printf("saved map to %s\n", name)
+	}
+}
+
+// editedMapPath returns where the edits of the map are saved by default: next
+// to the map, so the tilesets it refers to are found when it is loaded, with
+// _edited added to its name. The built-in maps have no directory on disk, so
+// their edits go to the working directory.
+func editedMapPath(g *Game) string {
+	name := strings.TrimSuffix(g.MapPath, path.Ext(g.MapPath)) + "_edited.tmx"
+	return filepath.Join(g.MapDir, filepath.FromSlash(name))
+}
+
+func copyTile(tile *tiled.LayerTile) *tiled.LayerTile {
+	t := *tile
+	return &t
+}
+
+// saveMap writes the map the game was loaded from to name, with the tile data
+// of its layers replaced by the edited tiles. Everything else in the file is
+// kept as it is, and each layer is stored with the encoding and compression
+// it was loaded with.
+func saveMap(g *Game, name string) error {
+	data, err := fs.ReadFile(g.MapFS, g.MapPath)
+	if err != nil {
+		return err
+	}
+	layers, err := findLayerData(data)
+	if err != nil {
+		return err
+	}
+	if len(layers) != len(g.GameMap.Layers) {
+		return fmt.Errorf("map has %d tile layers outside groups, loaded %d", len(layers), len(g.GameMap.Layers))
+	}
+
+	// replace the data from the end of the file so the offsets stay valid
+	for i := len(layers) - 1; i >= 0; i-- {
+		content, err := encodeLayerData(g.GameMap, g.GameMap.Layers[i], layers[i].encoding, layers[i].compression)
+		if err != nil {
+			return fmt.Errorf("layer %s: %w", g.GameMap.Layers[i].Name, err)
+		}
+		data = append(data[:layers[i].start], append([]byte(content), data[layers[i].end:]...)...)
+	}
+	return os.WriteFile(name, data, 0644)
+}
+
+// layerData is where the tiles of a layer are in a tmx file, between its
+// <data> and </data> tags.
+type layerData struct {
+	start, end            int64
+	encoding, compression string
+}
+
+// findLayerData finds the data of every tile layer directly in the map, in
+// the order they are in the file.
+func findLayerData(data []byte) ([]layerData, error) {
+	layers := []layerData{}
+	d := xml.NewDecoder(bytes.NewReader(data))
+	path := []string{}
+	for {
+		offset := d.InputOffset()
+		token, err := d.Token()
+		if err == io.EOF {
+			return layers, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch token := token.(type) {
+		case xml.StartElement:
+			path = append(path, token.Name.Local)
+			if strings.Join(path, "/") != "map/layer/data" {
+				continue
+			}
+			layer := layerData{start: d.InputOffset()}
+			for _, attr := range token.Attr {
+				switch attr.Name.Local {
+				case "encoding":
+					layer.encoding = attr.Value
+				case "compression":
+					layer.compression = attr.Value
+				}
+			}
+			layers = append(layers, layer)
+		case xml.EndElement:
+			if strings.Join(path, "/") == "map/layer/data" {
+				layers[len(layers)-1].end = offset
+			}
+			path = path[:len(path)-1]
+		}
+	}
+}
+
+// encodeLayerData formats the tiles of layer the way Tiled stores them with
+// encoding and compression: csv, base64 with or without gzip or zlib, or one
+// <tile> element per tile without an encoding.
+func encodeLayerData(gameMap *tiled.Map, layer *tiled.Layer, encoding, compression string) (string, error) {
+	if encoding != "base64" && compression != "" {
+		return "", fmt.Errorf("compression %q without base64", compression)
+	}
+	switch encoding {
+	case "csv":
+		return layerCSV(gameMap, layer), nil
+	case "":
+		var tiles strings.Builder
+		tiles.WriteString("\n")
+		for _, tile := range layer.Tiles {
+			if gid := tileGID(tile); gid == 0 {
+				tiles.WriteString("  <tile/>\n")
+			} else {
+				fmt.Fprintf(&tiles, "  <tile gid=\"%d\"/>\n", gid)
+			}
+		}
+		tiles.WriteString(" ")
+		return tiles.String(), nil
+	case "base64":
+	default:
+		return "", fmt.Errorf("unsupported encoding %q", encoding)
+	}
+
+	var raw bytes.Buffer
+	var w io.Writer = &raw
+	var compressor io.WriteCloser
+	switch compression {
+	case "":
+	case "gzip":
+		compressor = gzip.NewWriter(&raw)
+	case "zlib":
+		compressor = zlib.NewWriter(&raw)
+	default:
+		return "", fmt.Errorf("unsupported compression %q", compression)
+	}
+	if compressor != nil {
+		w = compressor
+	}
+	for _, tile := range layer.Tiles {
+		if err := binary.Write(w, binary.LittleEndian, tileGID(tile)); err != nil {
+			return "", err
+		}
+	}
+	if compressor != nil {
+		if err := compressor.Close(); err != nil {
+			return "", err
+		}
+	}
+	return "\n   " + base64.StdEncoding.EncodeToString(raw.Bytes()) + "\n  ", nil
+}
+
+// layerCSV formats the tiles of layer the way Tiled stores them.
+func layerCSV(gameMap *tiled.Map, layer *tiled.Layer) string {
+	var csv bytes.Buffer
+	csv.WriteString("\n")
+	for y := 0; y < gameMap.Height; y++ {
+		row := []string{}
+		for x := 0; x < gameMap.Width; x++ {
+			row = append(row, strconv.FormatUint(uint64(tileGID(layer.Tiles[y*gameMap.Width+x])), 10))
+		}
+		csv.WriteString(strings.Join(row, ","))
+		if y < gameMap.Height-1 {
+			csv.WriteString(",")
+		}
+		csv.WriteString("\n")
+	}
+	return csv.String()
+}
+
+func tileGID(tile *tiled.LayerTile) uint32 {
+	if tile.IsNil() {
+		return 0
+	}
+	gid := tile.Tileset.FirstGID + tile.ID
+	if tile.HorizontalFlip {
+		gid |= 0x80000000
+	}
+	if tile.VerticalFlip {
+		gid |= 0x40000000
+	}
+	if tile.DiagonalFlip {
+		gid |= 0x20000000
+	}
+	return gid
+}
@@ -0,0 +1,157 @@
+package game
+
+import (
+	"a-star/src/utils"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestCtrlSSavesMapWithoutMoving(t *testing.T) {
+	g := newTestGame(t, "map.tmx")
+	g.MapDir = t.TempDir()
+	g.Editor.Enabled = true
+	x, y := g.Player.XLoc, g.Player.YLoc
+
+	runTestGame(t, g, "1 press Control\n1 press S\n", 30)
+	if g.Player.XLoc != x || g.Player.YLoc != y {
+		t.Errorf("player walked from %d,%d to %d,%d on ctrl+S", x, y, g.Player.XLoc, g.Player.YLoc)
+	}
+	if _, err := os.Stat(filepath.Join(g.MapDir, "map_edited.tmx")); err != nil {
+		t.Errorf("map not saved next to the map: %s", err)
+	}
+}
+
+// writeEncodedMap writes map.tmx to dir with the data of every layer stored
+// with encoding and compression instead of csv.
+func writeEncodedMap(t *testing.T, g *Game, dir, encoding, compression string) {
+	t.Helper()
+	data, err := os.ReadFile("../../assets/map.tmx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	csvData := regexp.MustCompile(`(?s)<data encoding="csv">.*?</data>`)
+	i := 0
+	data = csvData.ReplaceAllFunc(data, func([]byte) []byte {
+		content, err := encodeLayerData(g.GameMap, g.GameMap.Layers[i], encoding, compression)
+		if err != nil {
+			t.Fatal(err)
+		}
+		i += 1
+		attrs := ""
+		if encoding != "" {
+			attrs += ` encoding="` + encoding + `"`
+		}
+		if compression != "" {
+			attrs += ` compression="` + compression + `"`
+		}
+		return []byte("<data" + attrs + ">" + content + "</data>")
+	})
+	if err := os.WriteFile(filepath.Join(dir, "map.tmx"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSaveMapKeepsEncoding(t *testing.T) {
+	tests := []struct{ encoding, compression string }{
+		{"csv", ""},
+		{"base64", ""},
+		{"base64", "gzip"},
+		{"base64", "zlib"},
+		{"", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.encoding+" "+test.compression, func(t *testing.T) {
+			g := newTestGame(t, "map.tmx")
+			dir := t.TempDir()
+			writeEncodedMap(t, g, dir, test.encoding, test.compression)
+			g.MapFS = os.DirFS(dir)
+			if _, err := loadMap(g.MapFS, "map.tmx"); err != nil {
+				t.Fatalf("encoded map doesn't load: %s", err)
+			}
+
+			paintCell(g, 18, 11, g.Editor.Brushes[0])
+			paintCell(g, 19, 11, g.Editor.Brushes[len(g.Editor.Brushes)-1])
+			if err := saveMap(g, filepath.Join(dir, "saved.tmx")); err != nil {
+				t.Fatal(err)
+			}
+			saved, err := loadMap(g.MapFS, "saved.tmx")
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, layer := range g.GameMap.Layers {
+				for j, tile := range layer.Tiles {
+					if got, want := tileGID(saved.Layers[i].Tiles[j]), tileGID(tile); got != want {
+						t.Fatalf("layer %s tile %d saved as %d, want %d", layer.Name, j, got, want)
+					}
+				}
+			}
+
+			data, err := os.ReadFile(filepath.Join(dir, "saved.tmx"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			layers, err := findLayerData(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, layer := range layers {
+				if layer.encoding != test.encoding || layer.compression != test.compression {
+					t.Fatalf("layer saved as %q %q", layer.encoding, layer.compression)
+				}
+			}
+		})
+	}
+}
+
+func TestSaveMapRejectsUnknownCompression(t *testing.T) {
+	g := newTestGame(t, "map.tmx")
+	dir := t.TempDir()
+	data, err := os.ReadFile("../../assets/map.tmx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data = []byte(strings.Replace(string(data), `<data encoding="csv">`, `<data encoding="base64" compression="zstd">`, 1))
+	if err := os.WriteFile(filepath.Join(dir, "map.tmx"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	g.MapFS = os.DirFS(dir)
+	if err := saveMap(g, filepath.Join(dir, "saved.tmx")); err == nil {
+		t.Fatal("saved a layer compressed with zstd")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "saved.tmx")); err == nil {
+		t.Fatal("a map was written anyway")
+	}
+}
+
+func TestFindFenceTile(t *testing.T) {
+	g := newTestGame(t, "map.tmx")
+	if g.FenceTile == nil || g.FenceTile.Tileset.Name != "fences" || g.FenceTile.ID != 26 {
+		t.Fatalf("fence tile %+v, want the one marked in the fences tileset", g.FenceTile)
+	}
+	if brush := g.Editor.Brushes[0]; !brush.Wall || brush.Tile != g.FenceTile {
+		t.Fatal("the wall brush doesn't paint fences")
+	}
+
+	// without a marked tile, the most used wall
+	for _, tileset := range g.GameMap.Tilesets {
+		for _, tile := range tileset.Tiles {
+			tile.Properties = nil
+		}
+	}
+	counts := map[uint32]int{}
+	most := uint32(0)
+	for _, tile := range g.GameMap.Layers[utils.CollisionLayer].Tiles {
+		if gid := tileGID(tile); gid != 0 {
+			counts[gid] += 1
+			if counts[gid] > counts[most] {
+				most = gid
+			}
+		}
+	}
+	if fence := findFenceTile(g.GameMap); fence == nil || tileGID(fence) != most {
+		t.Fatalf("fence tile %+v, want gid %d", fence, most)
+	}
+}
@@ -0,0 +1,34 @@
+package game
+
+import (
+	"a-star/src/astar"
+	"a-star/src/export"
+	"a-star/src/utils"
+	"fmt"
+)
+
+// exportSearch exports the search from the player to the cell at pixel x, y
+// with the algorithm and heuristic picked in the controls.
+func exportSearch(g *Game, x, y int) {
+	if replaying(g, "exporting the search") {
+		return
+	}
+	px, py := g.Player.GetCenterPoint()
+	origin, dest := cellAtPixel(g, px, py), cellAtPixel(g, x, y)
+	if origin == nil || dest == nil {
+		return
+	}
+	e := export.New(g.GridMap, origin, dest, astar.Algorithms[g.Algorithm], astar.Heuristics[g.Heuristic])
+	for _, ext := range []string{".json", ".svg"} {
+		if err := e.Save(utils.ExportPath + ext); err != nil {
+			fmt.Printf("error exporting search: %s\n", err.Error())
+			return
+		}
+	}
+	fmt.Printf("exported search to %s.json and %s.svg\n", utils.ExportPath, utils.ExportPath)
+}
+
+// cellAtPixel returns the cell of the map at pixel x, y, nil outside the map.
+func cellAtPixel(g *Game, x, y int) *astar.Cell {
+	return g.GridMap.GetGridCell(floorDiv(x, g.GameMap.TileWidth), floorDiv(y, g.GameMap.TileHeight))
+}
@@ -0,0 +1,116 @@
+package game
+
+import (
+	"a-star/src/astar"
+	"a-star/src/utils"
+
+	"github.com/lafriks/go-tiled"
+)
+
+// findFenceTile returns the tile drawn for fences and painted as walls by the
+// editor: the first tile with its "fence" property set, or else the tile used
+// most on the collision layer. It is nil if the map has neither.
+func findFenceTile(gameMap *tiled.Map) *tiled.LayerTile {
+	for _, tileset := range gameMap.Tilesets {
+		for _, tile := range tileset.Tiles {
+			if tile.Properties.GetBool("fence") {
+				return &tiled.LayerTile{ID: tile.ID, Tileset: tileset}
+			}
+		}
+	}
+
+	type tileKey struct {
+		tileset *tiled.Tileset
+		id      uint32
+	}
+	counts := map[tileKey]int{}
+	var fence *tiled.LayerTile
+	for _, tile := range gameMap.Layers[utils.CollisionLayer].Tiles {
+		if tile.IsNil() {
+			continue
+		}
+		key := tileKey{tile.Tileset, tile.ID}
+		counts[key] += 1
+		if fence == nil || counts[key] > counts[tileKey{fence.Tileset, fence.ID}] {
+			fence = &tiled.LayerTile{ID: tile.ID, Tileset: tile.Tileset}
+		}
+	}
+	return fence
+}
+
+// toggleFence places a fence on the cell at pixel x, y, or takes away the one
+// already there. Fences can't be placed on walls or on top of anyone.
+func toggleFence(g *Game, x, y int) {
+	cell := g.GridMap.GetGridCell(x/utils.UnitSize, y/utils.UnitSize)
+	if cell == nil {
+		return
+	}
+	if fence, ok := g.Fences[cell]; ok {
+		delete(g.Fences, cell)
+		g.GridMap.RemoveObstacle(fence)
+		return
+	}
+	if !cell.IsWalkable || isCellOccupied(g, cell) {
+		return
+	}
+
+	fence := &astar.Obstacle{Cells: []*astar.Cell{cell}}
+	g.Fences[cell] = fence
+	g.GridMap.AddObstacle(fence)
+}
+
+func isCellOccupied(g *Game, cell *astar.Cell) bool {
+	body := cellBody(g, cell)
+	if hasCollision(0, 0, g.Player.Collision, body) {
+		return true
+	}
+	for _, c := range g.Chickens {
+		if hasCollision(0, 0, c.Collision, body) {
+			return true
+		}
+	}
+	return false
+}
+
+// onMapChanged is called whenever obstacles change the walkability or cost of
+// cells. The flow field is repaired and chickens whose path runs through a
+// blocked cell look for a new way.
+func onMapChanged(g *Game, cells []*astar.Cell) {
+	g.FlowField.Repair(cells)
+	for _, c := range g.Chickens {
+		if c.Path != nil && c.Path.IsBlocked() {
+			c.Replan(g)
+		}
+	}
+}
+
+// Replan looks for a new path to where the chicken's path ends, e.g. after a
+// cell along it has been blocked.
+func (c *Chicken) Replan(g *Game) {
+	if len(c.Path.Cells) == 0 {
+		return
+	}
+	dest := c.Path.Cells[len(c.Path.Cells)-1]
+	cell := c.GetCell()
+
+	c.Path = g.FindPath(cell, dest)
+	if c.Path == nil {
+		c.Path = &astar.Path{}
+	}
+	if !c.IsOnCell() {
+		// step back onto the cell the new path starts from first
+		c.Path.Cells = append([]*astar.Cell{g.GridMap.GetGridCell(cell.X, cell.Y)}, c.Path.Cells...)
+	}
+	if len(c.Path.Cells) == 0 {
+		c.Path = nil
+	}
+}
+
+func cellBody(g *Game, cell *astar.Cell) CollisionBody {
+	return CollisionBody{
+		X:      cell.X * g.GameMap.TileWidth,
+		Y:      cell.Y * g.GameMap.TileHeight,
+		Width:  g.GameMap.TileWidth,
+		Height: g.GameMap.TileHeight,
+	}
+}
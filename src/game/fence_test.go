@@ -0,0 +1,74 @@
+package game
+
+import (
+	"a-star/src/astar"
+	"a-star/src/utils"
+	"testing"
+)
+
+func TestToggleFence(t *testing.T) {
+	g := newTestGame(t, "map.tmx")
+	notified := 0
+	g.GridMap.Subscribe(func(cells []*astar.Cell) { notified += len(cells) })
+	cell := g.GridMap.GetGridCell(19, 12)
+	x, y := cell.X*utils.UnitSize, cell.Y*utils.UnitSize
+
+	toggleFence(g, x, y)
+	if cell.IsWalkable || g.Fences[cell] == nil || notified != 1 {
+		t.Fatalf("fenced cell walkable %v, %d cells notified", cell.IsWalkable, notified)
+	}
+	toggleFence(g, x, y)
+	if !cell.IsWalkable || len(g.Fences) != 0 || notified != 2 {
+		t.Fatalf("unfenced cell walkable %v, %d cells notified", cell.IsWalkable, notified)
+	}
+
+	// no fences on walls or on anyone
+	toggleFence(g, 0, 0)
+	px, py := g.Player.GetCenterPoint()
+	toggleFence(g, px, py)
+	cx, cy := g.Chickens[0].GetCenterPoint()
+	toggleFence(g, cx, cy)
+	if len(g.Fences) != 0 || notified != 2 {
+		t.Fatalf("%d fences placed on walls or characters", len(g.Fences))
+	}
+}
+
+func TestFenceMakesChickensReplan(t *testing.T) {
+	g := newTestGame(t, "map.tmx")
+	c := g.Chickens[0]
+	c.Restart(g, 14*utils.UnitSize, 9*utils.UnitSize)
+	dest := g.GridMap.GetGridCell(24, 9)
+	c.SetPathToCell(g, dest)
+	fenced := g.GridMap.GetGridCell(19, 9)
+	onPath := false
+	for _, cell := range c.Path.Cells {
+		onPath = onPath || cell == fenced
+	}
+	if !onPath {
+		t.Fatal("path doesn't run along the open row")
+	}
+
+	toggleFence(g, fenced.X*utils.UnitSize, fenced.Y*utils.UnitSize)
+	if fenced.IsWalkable {
+		t.Fatal("fence wasn't placed")
+	}
+	if c.Path == nil || len(c.Path.Cells) == 0 || c.Path.Cells[len(c.Path.Cells)-1] != dest {
+		t.Fatalf("replanned path %v doesn't lead to %d,%d", c.Path, dest.X, dest.Y)
+	}
+	for _, cell := range c.Path.Cells {
+		if cell == fenced || !cell.IsWalkable {
+			t.Fatalf("replanned path runs through %d,%d", cell.X, cell.Y)
+		}
+	}
+	if c.Path.Cost() != astar.Dijkstra(g.GridMap, g.GridMap.GetGridCell(14, 9), dest).Cost() {
+		t.Fatalf("replanned path costs %g, more than the way round the fence", c.Path.Cost())
+	}
+
+	// the shared flow field goes round the fence too
+	g.FlowField.SetGoal(dest)
+	for cell := g.GridMap.GetGridCell(14, 9); cell != nil; cell = g.FlowField.Next(cell) {
+		if cell == fenced {
+			t.Fatal("flow field runs through the fence")
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package game
+
+import (
+	"a-star/src/astar"
+	"a-star/src/capture"
+	"a-star/src/input"
+	"a-star/src/utils"
+	"fmt"
+	"image"
+	"io"
+)
+
+// Runner plays a game without opening a window, with its input coming from a
+// script. Each Step is one frame, which runs as many ticks as the clock says.
+// Nothing of the game depends on Ebiten, so no display is needed.
+type Runner struct {
+	Game    *Game
+	Script  *input.Script
+	Capture *capture.Capture // when set, gets the grid drawn after every frame
+}
+
+// NewRunner makes g read its input from script and skips the level select
+// screen, which scripts can still open with Escape.
+func NewRunner(g *Game, script *input.Script) *Runner {
+	g.Input = script
+	g.Screen = utils.PlayScreen
+	return &Runner{Game: g, Script: script}
+}
+
+// Step runs the next frame of the script.
+func (r *Runner) Step() error {
+	// Ebiten would lay out the screen before every frame
+	r.Game.Layout(r.Game.Camera.Width, r.Game.Camera.Height)
+	if err := r.Game.Update(); err != nil {
+		return err
+	}
+	if r.Capture != nil {
+		r.Capture.Frame(func() image.Image { return RenderGrid(r.Game) })
+	}
+	return nil
+}
+
+// RunUntil steps until done reports true or maxFrames frames have run, and
+// returns the number of frames run and whether done was reached.
+func (r *Runner) RunUntil(maxFrames int, done func(g *Game) bool) (int, bool, error) {
+	for frame := 1; frame <= maxFrames; frame++ {
+		if err := r.Step(); err != nil {
+			return frame, false, err
+		}
+		if done != nil && done(r.Game) {
+			return frame, true, nil
+		}
+	}
+	return maxFrames, false, nil
+}
+
+// ChickensAtPlayer reports whether every chicken is on the player's cell.
+func ChickensAtPlayer(g *Game) bool {
+	playerCell := playerCell(g)
+	for _, c := range g.Chickens {
+		cell := c.GetCell()
+		if cell.X != playerCell.X || cell.Y != playerCell.Y {
+			return false
+		}
+	}
+	return true
+}
+
+func playerCell(g *Game) *astar.Cell {
+	px, py := g.Player.GetCenterPoint()
+	return astar.GetCell(px, py)
+}
+
+// WriteState writes where the player and each chicken are.
+func WriteState(w io.Writer, g *Game) {
+	cell := playerCell(g)
+	fmt.Fprintf(w, "tick %d\n", g.Clock.Tick)
+	fmt.Fprintf(w, "player   cell %d,%d\n", cell.X, cell.Y)
+	for i, c := range g.Chickens {
+		cell := c.GetCell()
+		fmt.Fprintf(w, "chicken %d cell %d,%d\n", i, cell.X, cell.Y)
+	}
+}
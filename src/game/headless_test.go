@@ -0,0 +1,40 @@
+package game
+
+import (
+	"a-star/src/input"
+	"strings"
+	"testing"
+)
+
+func TestChickensReachPlayerAfterPlay(t *testing.T) {
+	for _, name := range []string{"map.tmx", "maze.tmx"} {
+		t.Run(name, func(t *testing.T) {
+			g := newTestGame(t, name)
+			// the play button is the first of the controls, at the top left
+			script, err := input.ParseScript(strings.NewReader("1 click 8 8\n"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			runner := NewRunner(g, script)
+			frames, reached, err := runner.RunUntil(3000, ChickensAtPlayer)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reached {
+				var state strings.Builder
+				WriteState(&state, g)
+				t.Fatalf("chickens not all on the player's cell after %d frames:\n%s", frames, state.String())
+			}
+			t.Logf("every chicken reached the player after %d frames", frames)
+		})
+	}
+}
+
+func TestChickensDontGatherWithoutPlay(t *testing.T) {
+	g := newTestGame(t, "map.tmx")
+	runner := NewRunner(g, input.NewScript(nil))
+	if frames, reached, _ := runner.RunUntil(600, ChickensAtPlayer); reached {
+		t.Fatalf("chickens all on the player's cell after %d frames without play", frames)
+	}
+}
@@ -2,123 +2,216 @@ package game
 
 import (
 	"a-star/src/astar"
+	"a-star/src/capture"
+	"a-star/src/input"
+	"a-star/src/ui"
 	"a-star/src/utils"
-	"embed"
+	"bytes"
+	"encoding/xml"
 	"fmt"
-	"log"
+	"image"
+	_ "image/png"
+	"io"
+	"io/fs"
+	"math/rand"
 	"os"
-	"path"
+	"path/filepath"
 	"strings"
+	"time"
 
-	"github.com/co0p/tankism/lib/collision"
-	"github.com/hajimehoshi/ebiten/v2"
-	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
-	"github.com/hajimehoshi/ebiten/v2/inpututil"
 	"github.com/lafriks/go-tiled"
 )
 
+// Game is the simulation: the map, everyone on it and the controls. It
+// doesn't draw anything or open a window, that is up to its View, so it runs
+// the same with or without one.
 type Game struct {
-	GameMap        *tiled.Map
-	GridMap        *astar.GridMap
-	Tilesets       map[string]*ebiten.Image
-	Player         *Player
-	Chickens       []*Chicken
-	CurrentFrame   int
-	EmbeddedAssets embed.FS
+	GameMap    *tiled.Map
+	GridMap    *astar.GridMap
+	FlowField  *astar.FlowField
+	Player     *Player
+	Chickens   []*Chicken
+	Fences     map[*astar.Cell]*astar.Obstacle // fences placed by the player
+	FenceTile  *tiled.LayerTile                // drawn for fences, nil if the map has none
+	Editor     *Editor
+	Controls   *ui.UI
+	Camera     *Camera
+	MapPath    string
+	Levels     []Level
+	Level      int // index of the level being played
+	Exits      []Exit
+	onExit     bool // the player was in an exit on the last tick
+	Screen     int
+	Algorithm  int // index in astar.Algorithms of the search chickens use
+	Heuristic  int // index in astar.Heuristics
+	SearchView *SearchView
+	Clock      Clock
+	Rand       *rand.Rand
+	random     *randSource      // the source of Rand, which counts what it draws
+	Input      input.Input      // keyboard and mouse, or a script playing them
+	Device     input.Device     // keyboard and mouse of the window, nil without one
+	View       View             // nil without a window
+	Recording  *Recording       // set while the session is being recorded
+	Replay     *Replay          // set while a recording is played back
+	Capture    *capture.Capture // set while the screen is captured
+	Assets     fs.FS            // sprites and behaviour trees
+	MapFS      fs.FS            // the map and the tilesets it uses
+	MapDir     string           // directory on disk MapFS is opened from, empty for the built-in maps
+	images     map[string]image.Image
 }
 
-func NewGame(embeddedAssets embed.FS) *Game {
-	gameMap, err := loadMapFromEmbedded(embeddedAssets, "assets/map.tmx")
-	if err != nil {
-		fmt.Printf("error parsing map: %s", err.Error())
+// View shows a game in a window. The game tells it when a level is about to
+// be loaded, so it can load the images the level needs and stop the level
+// from loading if it can't, and when the tiles of a cell have changed.
+type View interface {
+	LoadLevel(level Level, gameMap *tiled.Map) error
+	TilesChanged(x, y int)
+}
+
+// NewGame loads the first of levels, with maps and their tilesets coming from
+// mapFS and everything else from assets. With more than one level the game
+// starts on the level select screen. It reads no input until it gets some,
+// e.g. from a window or a script.
+func NewGame(assets fs.FS, mapFS fs.FS, levels []Level) *Game {
+	g := &Game{
+		Levels: levels,
+		Input:  input.NewScript(nil),
+		Assets: assets,
+		MapFS:  mapFS,
+		images: map[string]image.Image{},
+	}
+	g.SetSeed(time.Now().UnixNano())
+	if err := g.LoadLevel(0); err != nil {
+		fmt.Printf("error loading level: %s", err.Error())
 		os.Exit(2)
 	}
-	windowWidth := gameMap.Width * gameMap.TileWidth
-	windowHeight := gameMap.Height * gameMap.TileHeight
-	ebiten.SetWindowSize(windowWidth, windowHeight)
-	ebiten.SetWindowTitle("A Star Algorithm Implementation")
+	if len(levels) > 1 {
+		g.Screen = utils.LevelSelectScreen
+	}
 
-	spawnPoint := gameMap.ObjectGroups[utils.PlayerSpawnPoint].Objects[0]
-	player := NewPlayer(embeddedAssets, int(spawnPoint.X), int(spawnPoint.Y))
-	chickens := NewChickens(embeddedAssets, gameMap)
+	g.Camera = NewCamera(g.WindowSize())
+	return g
+}
 
-	return &Game{
-		GameMap:        gameMap,
-		GridMap:        astar.NewGridMap(gameMap),
-		Tilesets:       getTilesets(embeddedAssets),
-		Player:         player,
-		Chickens:       chickens,
-		EmbeddedAssets: embeddedAssets,
+// WindowSize returns the size of a window showing the whole map, or as much
+// of it as fits.
+func (g *Game) WindowSize() (width, height int) {
+	return min(g.GameMap.Width*g.GameMap.TileWidth, utils.MaxWindowWidth),
+		min(g.GameMap.Height*g.GameMap.TileHeight, utils.MaxWindowHeight)
+}
+
+// SetSeed restarts the random numbers the simulation uses from seed.
+func (g *Game) SetSeed(seed int64) {
+	g.setRandState(seed, 0)
+}
+
+// setRandState restarts the random numbers from seed and skips the first
+// draws of them, which puts them back where a saved game left them.
+func (g *Game) setRandState(seed, draws int64) {
+	g.random = &randSource{Source64: rand.NewSource(seed).(rand.Source64), seed: seed}
+	for g.random.draws < draws {
+		g.random.Int63()
 	}
+	g.Rand = rand.New(g.random)
+}
+
+// randSource counts the numbers drawn from a seeded source. The state of a
+// source can't be read, but the seed and the count are enough to get back to
+// it.
+type randSource struct {
+	rand.Source64
+	seed  int64
+	draws int64
+}
+
+func (s *randSource) Int63() int64 {
+	s.draws += 1
+	return s.Source64.Int63()
+}
+
+func (s *randSource) Uint64() uint64 {
+	s.draws += 1
+	return s.Source64.Uint64()
+}
+
+func (s *randSource) Seed(seed int64) {
+	s.Source64.Seed(seed)
+	s.seed, s.draws = seed, 0
 }
 
 func (g *Game) Update() error {
-	g.CurrentFrame += 1
-	g.Player.UpdateFrame(g.CurrentFrame)
+	if g.Replay != nil && g.Replay.Finished() {
+		endReplay(g)
+	}
+	g.Input.Advance()
+	if g.Screen == utils.LevelSelectScreen {
+		updateLevelSelect(g)
+		return nil
+	}
+
+	g.Controls.Update(g.Input)
 	getPlayerInput(g)
+	updateEditor(g)
+	for i := g.Clock.Ticks(); i > 0 && g.Screen == utils.PlayScreen; i-- {
+		g.tick()
+	}
+	updateCamera(g)
+	return nil
+}
+
+// tick advances the simulation by one tick of the clock.
+func (g *Game) tick() {
+	g.Clock.Tick += 1
+	g.Player.UpdateFrame(g.Clock.Tick)
+	movePlayer(g)
 
 	// update chickens
 	for i, c := range g.Chickens {
-		g.Chickens[i].UpdateFrame(g.CurrentFrame)
-
-		// if chicken has a path, walk to path
-		if c.Path != nil {
-			nextCell := c.Path.GetCurrentCell()
-			if nextCell == nil {
-				// already at destination
-				if c.State == utils.ChickenWalkState {
-					g.Chickens[i].State = utils.ChickenIdleState
-				}
-				continue
-			}
-
-			if nextCell.X*32 == c.XLoc && nextCell.Y*32 == c.YLoc {
-				g.Chickens[i].Path.Next()
-				continue
-			}
+		g.Chickens[i].UpdateFrame(g.Clock.Tick)
 
-			if nextCell.X*32 < c.XLoc {
-				// walk left
-				g.Chickens[i].Direction = utils.ChickenLeft
-				g.Chickens[i].State = utils.ChickenWalkState
-				g.Chickens[i].Dx -= utils.ChickenMovementSpeed
-				g.Chickens[i].UpdateLocation()
-			} else if nextCell.X*32 > c.XLoc {
-				// walk right
-				g.Chickens[i].Direction = utils.ChickenRight
-				g.Chickens[i].State = utils.ChickenWalkState
-				g.Chickens[i].Dx += utils.ChickenMovementSpeed
-				g.Chickens[i].UpdateLocation()
-			} else if nextCell.Y*32 < c.YLoc {
-				// walk down
-				g.Chickens[i].State = utils.ChickenWalkState
-				g.Chickens[i].Dy -= utils.ChickenMovementSpeed
-				g.Chickens[i].UpdateLocation()
-			} else if nextCell.Y*32 > c.YLoc {
-				// walk up
-				g.Chickens[i].State = utils.ChickenWalkState
-				g.Chickens[i].Dy += utils.ChickenMovementSpeed
-				g.Chickens[i].UpdateLocation()
-			}
+		// decide where to go next, a forced behaviour like the chase started by
+		// the play button takes over from the tree until it is finished
+		if c.Tree != nil && !c.BehaviourForced {
+			updateBehaviourTree(c)
+		} else {
+			updateBehaviour(g, c)
 		}
+
+		// if chicken has a path, walk to path
+		c.FollowPath()
 	}
-	return nil
-}
 
-func (g *Game) Draw(screen *ebiten.Image) {
-	drawOptions := ebiten.DrawImageOptions{}
-	drawMap(g.GameMap, g.Tilesets, screen, drawOptions)
-	drawPlayer(g.Player, screen, drawOptions)
-	drawChickens(g.Chickens, screen, drawOptions)
-	drawButtons(g, screen, drawOptions)
+	// keep chickens from walking through each other and the player
+	steerChickens(g)
+
+	stepSearchView(g)
+	checkExits(g)
+	afterTick(g)
 }
 
+// Layout is called with the size of the window before every frame and
+// returns the size of the screen to draw.
 func (g *Game) Layout(oWidth, oHeight int) (sWidth, sHeight int) {
+	if g.Replay != nil {
+		// keep the screen the recording had, Ebiten scales it to the window
+		oWidth, oHeight = g.Replay.screenSize(oWidth, oHeight)
+	} else if g.Recording != nil {
+		g.Recording.layout(oWidth, oHeight)
+	}
+	g.Camera.Width, g.Camera.Height = oWidth, oHeight
 	return oWidth, oHeight
 }
 
-func getPlayerInput(g *Game) {
-	if ebiten.IsKeyPressed(ebiten.KeyA) && g.Player.Sprite.X > 0 {
+// movePlayer walks the player in the direction of the keys held down.
+func movePlayer(g *Game) {
+	// keys held with ctrl are shortcuts, e.g. ctrl+S saves the map
+	if g.Input.IsKeyPressed(input.KeyControl) {
+		if g.Player.StateTTL == 0 {
+			g.Player.State = utils.IdleState
+		}
+		return
+	}
+	if g.Input.IsKeyPressed(input.KeyA) && g.Player.Sprite.X > 0 {
 		g.Player.Direction = utils.Left
 		g.Player.State = utils.WalkState
 		g.Player.Dx -= utils.PlayerMovementSpeed
@@ -127,7 +220,7 @@ func getPlayerInput(g *Game) {
 		} else {
 			g.Player.Dx = 0
 		}
-	} else if ebiten.IsKeyPressed(ebiten.KeyD) &&
+	} else if g.Input.IsKeyPressed(input.KeyD) &&
 		g.Player.Sprite.X < (g.GameMap.Width*g.GameMap.TileWidth)-g.Player.Sprite.Width {
 		g.Player.Direction = utils.Right
 		g.Player.State = utils.WalkState
@@ -137,7 +230,7 @@ func getPlayerInput(g *Game) {
 		} else {
 			g.Player.Dx = 0
 		}
-	} else if ebiten.IsKeyPressed(ebiten.KeyW) && g.Player.Sprite.Y > 0 {
+	} else if g.Input.IsKeyPressed(input.KeyW) && g.Player.Sprite.Y > 0 {
 		g.Player.Direction = utils.Back
 		g.Player.State = utils.WalkState
 		g.Player.Dy -= utils.PlayerMovementSpeed
@@ -146,7 +239,7 @@ func getPlayerInput(g *Game) {
 		} else {
 			g.Player.Dy = 0
 		}
-	} else if ebiten.IsKeyPressed(ebiten.KeyS) &&
+	} else if g.Input.IsKeyPressed(input.KeyS) &&
 		g.Player.Sprite.Y < (g.GameMap.Height*g.GameMap.TileHeight)-g.Player.Sprite.Height {
 		g.Player.Direction = utils.Front
 		g.Player.State = utils.WalkState
@@ -159,85 +252,137 @@ func getPlayerInput(g *Game) {
 	} else if g.Player.StateTTL == 0 {
 		g.Player.State = utils.IdleState
 	}
+}
 
-	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
-		mouseX, mouseY := ebiten.CursorPosition()
-		if isClicked(mouseX, mouseY, CollisionBody{
-			X:      6,
-			Y:      4,
-			Width:  180,
-			Height: 54,
-		}) {
-			for i := range g.Chickens {
-				g.Chickens[i].SetPath(g, g.Player.XLoc, g.Player.YLoc)
-			}
-		} else if isClicked(mouseX, mouseY, CollisionBody{
-			X:      6,
-			Y:      4 + 58,
-			Width:  180,
-			Height: 54,
-		}) {
-			g.Player.Restart(g)
-			for i, spawnPoint := range g.GameMap.ObjectGroups[utils.ChickenSpawnPoints].Objects {
-				g.Chickens[i].Restart(g, int(spawnPoint.X), int(spawnPoint.Y))
-			}
-		}
+func getPlayerInput(g *Game) {
+	if g.Input.IsKeyJustPressed(input.KeyEscape) {
+		g.Screen = utils.LevelSelectScreen
+	}
+
+	if g.Input.IsKeyJustPressed(input.KeyF) && !g.Controls.HasMouse() {
+		mouseX, mouseY := g.Camera.ScreenToWorld(g.Input.CursorPosition())
+		toggleFence(g, mouseX, mouseY)
+	}
+	if g.Input.IsKeyJustPressed(input.KeyV) {
+		mouseX, mouseY := g.Camera.ScreenToWorld(g.Input.CursorPosition())
+		startSearchView(g, mouseX, mouseY)
+	}
+	if g.Input.IsKeyJustPressed(input.KeyG) {
+		toggleCapture(g)
+	}
+	if g.Input.IsKeyJustPressed(input.KeyX) {
+		mouseX, mouseY := g.Camera.ScreenToWorld(g.Input.CursorPosition())
+		exportSearch(g, mouseX, mouseY)
 	}
 }
 
 func isClicked(x, y int, body CollisionBody) bool {
 	// check if mouse clicked on a body
-	aBounds := collision.BoundingBox{
-		X:      float64(x),
-		Y:      float64(y),
-		Width:  1,
-		Height: 1,
-	}
-	bBounds := collision.BoundingBox{
-		X:      float64(body.X),
-		Y:      float64(body.Y),
-		Width:  float64(body.Width),
-		Height: float64(body.Height),
-	}
-	if collision.AABBCollision(aBounds, bBounds) {
-		return true
+	return hasCollision(0, 0, CollisionBody{X: x, Y: y, Width: 1, Height: 1}, body)
+}
+
+// assetImage returns the image at path in the assets, or nil if it can't be
+// loaded. Images are only loaded once.
+func (g *Game) assetImage(path string) image.Image {
+	if img, ok := g.images[path]; ok {
+		return img
 	}
-	return false
+	img := loadImage(g.Assets, path)
+	g.images[path] = img
+	return img
 }
 
-func loadImage(EmbeddedAssets embed.FS, filepath string) *ebiten.Image {
-	embeddedFile, err := EmbeddedAssets.Open(filepath)
+func loadImage(assets fs.FS, filepath string) image.Image {
+	file, err := assets.Open(filepath)
 	if err != nil {
 		return nil
 	}
-	image, _, err := ebitenutil.NewImageFromReader(embeddedFile)
+	defer file.Close()
+	img, _, err := image.Decode(file)
 	if err != nil {
 		return nil
 	}
-	return image
+	return img
 }
 
-func loadMapFromEmbedded(embeddedAssets embed.FS, name string) (*tiled.Map, error) {
-	embeddedMap, err := tiled.LoadFile(name,
-		tiled.WithFileSystem(embeddedAssets))
+// OpenMap returns a file system of the nearest directory holding the map file
+// name and every tileset, template and image it refers to, along with that
+// directory and the path of the map within it. A file system rooted at the
+// map's own directory couldn't open files the map refers to from elsewhere,
+// e.g. ../tilesets/fences.tsx.
+func OpenMap(name string) (mapFS fs.FS, root, mapPath string, err error) {
+	abs, err := filepath.Abs(name)
 	if err != nil {
-		return nil, err
+		return nil, "", "", err
 	}
-	return embeddedMap, nil
+	files, err := mapFiles(abs, map[string]bool{})
+	if err != nil {
+		return nil, "", "", err
+	}
+	root = filepath.Dir(abs)
+	for _, file := range files {
+		for !strings.HasPrefix(file, root+string(filepath.Separator)) && root != filepath.Dir(root) {
+			root = filepath.Dir(root)
+		}
+	}
+	mapPath, err = filepath.Rel(root, abs)
+	if err != nil {
+		return nil, "", "", err
+	}
+	return os.DirFS(root), root, filepath.ToSlash(mapPath), nil
 }
 
-func getTilesets(embeddedAssets embed.FS) map[string]*ebiten.Image {
-	tilesets := map[string]*ebiten.Image{}
-	for _, tsPath := range utils.TilesetSheets {
-		embeddedFile, err := embeddedAssets.Open(path.Join("assets", tsPath))
-		if err != nil {
-			log.Fatal("failed to load embedded image:", embeddedFile, err)
+// mapFiles returns the files the map, tileset or template file name refers
+// to, and the ones those refer to in turn. Files already in seen are skipped.
+func mapFiles(name string, seen map[string]bool) ([]string, error) {
+	seen[name] = true
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	files := []string{}
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return files, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		element, ok := token.(xml.StartElement)
+		if !ok {
+			continue
 		}
-		tsImage, _, err := ebitenutil.NewImageFromReader(embeddedFile)
-		if err != nil {
-			fmt.Println("error loading tileset image")
+		for _, attr := range element.Attr {
+			if attr.Name.Local != "source" && attr.Name.Local != "template" {
+				continue
+			}
+			file := filepath.Join(filepath.Dir(name), filepath.FromSlash(attr.Value))
+			if seen[file] {
+				continue
+			}
+			files = append(files, file)
+			if element.Name.Local == "image" {
+				seen[file] = true
+				continue
+			}
+			more, err := mapFiles(file, seen)
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, more...)
 		}
-		tilesets[strings.Split(tsPath, ".")[0]] = tsImage
 	}
-	return tilesets
+}
+
+func loadMap(mapFS fs.FS, name string) (*tiled.Map, error) {
+	gameMap, err := tiled.LoadFile(name,
+		tiled.WithFileSystem(mapFS))
+	if err != nil {
+		return nil, err
+	}
+	if err := checkMap(gameMap); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return gameMap, nil
 }
@@ -0,0 +1,138 @@
+package game
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestOpenMapFindsTilesetsOutsideItsDirectory(t *testing.T) {
+	// the map's fences tileset moves to ../tilesets, its image to ../images
+	dir := t.TempDir()
+	for _, sub := range []string{"maps", "tilesets", "images"} {
+		if err := os.Mkdir(filepath.Join(dir, sub), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	data, err := os.ReadFile("../../assets/map.tmx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	inline := ` <tileset firstgid="78" name="fences" tilewidth="32" tileheight="32" tilecount="32" columns="8">
+  <image source="fences.png" width="256" height="128"/>
+  <tile id="26">
+   <properties>
+    <property name="fence" type="bool" value="true"/>
+   </properties>
+  </tile>
+ </tileset>`
+	if !strings.Contains(string(data), inline) {
+		t.Fatal("no fences tileset in map.tmx")
+	}
+	files := map[string]string{
+		"maps/level.tmx": strings.Replace(string(data), inline, ` <tileset firstgid="78" source="../tilesets/fences.tsx"/>`, 1),
+		"tilesets/fences.tsx": `<?xml version="1.0" encoding="UTF-8"?>
+<tileset name="fences" tilewidth="32" tileheight="32" tilecount="32" columns="8">
+ <image source="../images/fences.png" width="256" height="128"/>
+ <tile id="26">
+  <properties>
+   <property name="fence" type="bool" value="true"/>
+  </properties>
+ </tile>
+</tileset>`,
+	}
+	png, err := os.ReadFile("../../assets/fences.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	files["images/fences.png"] = string(png)
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	mapFS, root, mapPath, err := OpenMap(filepath.Join(dir, "maps", "level.tmx"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if root != dir || mapPath != "maps/level.tmx" {
+		t.Fatalf("map opened as %s in %s, want the directory holding its tilesets", mapPath, root)
+	}
+	gameMap, err := loadMap(mapFS, mapPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, ts := range gameMap.Tilesets {
+		if ts.Name != "fences" {
+			continue
+		}
+		// the way the view opens tileset images
+		image := path.Clean(filepath.ToSlash(ts.GetFileFullPath(ts.Image.Source)))
+		if _, err := fs.Stat(mapFS, image); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	t.Fatal("fences tileset not loaded")
+}
+
+func TestOpenMapStaysInItsDirectory(t *testing.T) {
+	abs, err := filepath.Abs("../../assets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, root, mapPath, err := OpenMap("../../assets/map.tmx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if root != abs || mapPath != "map.tmx" {
+		t.Fatalf("map opened as %s in %s", mapPath, root)
+	}
+}
+
+func TestLoadMapRejectsIncompleteMaps(t *testing.T) {
+	data, err := os.ReadFile("../../assets/map.tmx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	collision := regexp.MustCompile(`(?s) <layer id="2".*?</layer>\n`)
+	playerSpawn := regexp.MustCompile(`(?s)(<objectgroup id="3" name="PlayerSpawnPoint">).*?(</objectgroup>)`)
+	groups := regexp.MustCompile(`(?s) <objectgroup.*</objectgroup>\n`)
+	tests := []struct {
+		name string
+		edit func(string) string
+	}{
+		{"no collision layer", func(s string) string { return collision.ReplaceAllString(s, "") }},
+		{"no player spawn point", func(s string) string { return playerSpawn.ReplaceAllString(s, "$1$2") }},
+		{"no object groups", func(s string) string { return groups.ReplaceAllString(s, "") }},
+		{"layers smaller than the map", func(s string) string {
+			return strings.Replace(s, `width="30" height="20" tilewidth`, `width="31" height="20" tilewidth`, 1)
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			edited := test.edit(string(data))
+			if edited == string(data) {
+				t.Fatal("map wasn't edited")
+			}
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, "map.tmx"), []byte(edited), 0644); err != nil {
+				t.Fatal(err)
+			}
+			g := newTestGame(t, "map.tmx")
+			g.MapFS = os.DirFS(dir)
+			gameMap := g.GameMap
+			if err := g.LoadLevel(0); err == nil {
+				t.Fatal("loaded the map")
+			}
+			if g.GameMap != gameMap {
+				t.Fatal("the level changed anyway")
+			}
+		})
+	}
+}
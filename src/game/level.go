@@ -0,0 +1,222 @@
+package game
+
+import (
+	"a-star/src/astar"
+	"a-star/src/input"
+	"a-star/src/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"github.com/lafriks/go-tiled"
+)
+
+type Level struct {
+	Name string `json:"name"`
+	Map  string `json:"map"` // path of the tmx file in the map file system
+}
+
+// Exit is a zone of the map that takes the player to another level.
+type Exit struct {
+	Body  CollisionBody
+	Level int // index of the level in Game.Levels, -1 for the level select screen
+}
+
+// LoadLevels reads the list of levels from the levels file in assets. Without
+// one there is a single level on map.tmx.
+func LoadLevels(assets fs.FS) ([]Level, error) {
+	data, err := fs.ReadFile(assets, utils.LevelsFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return []Level{{Name: "map", Map: "map.tmx"}}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	levels := []Level{}
+	if err := json.Unmarshal(data, &levels); err != nil {
+		return nil, fmt.Errorf("%s: %w", utils.LevelsFile, err)
+	}
+	if len(levels) == 0 {
+		return nil, fmt.Errorf("%s: no levels", utils.LevelsFile)
+	}
+	return levels, nil
+}
+
+// LoadLevel replaces the map, its grid and everyone on it with those of level
+// i, then starts playing it. Nothing changes if the level can't be loaded.
+func (g *Game) LoadLevel(i int) error {
+	gameMap, err := loadMap(g.MapFS, g.Levels[i].Map)
+	if err != nil {
+		return err
+	}
+	return g.startLevel(i, gameMap)
+}
+
+// startLevel plays level i on gameMap, freshly loaded from the level's map.
+func (g *Game) startLevel(i int, gameMap *tiled.Map) error {
+	level := g.Levels[i]
+	if g.View != nil {
+		if err := g.View.LoadLevel(level, gameMap); err != nil {
+			return err
+		}
+	}
+
+	spawnPoint := gameMap.ObjectGroups[utils.PlayerSpawnPoint].Objects[0]
+	gridMap := astar.NewGridMap(gameMap)
+
+	g.Level = i
+	g.GameMap = gameMap
+	g.GridMap = gridMap
+	g.FlowField = astar.NewFlowField(gridMap)
+	g.Player = NewPlayer(int(spawnPoint.X), int(spawnPoint.Y))
+	g.Chickens = NewChickens(gameMap, LoadPatrolRoutes(gameMap, gridMap))
+	g.Fences = map[*astar.Cell]*astar.Obstacle{}
+	g.FenceTile = findFenceTile(gameMap)
+	g.Editor = NewEditor(gameMap, g.FenceTile)
+	g.Controls = newControls(g)
+	g.MapPath = level.Map
+	g.Exits = loadExits(g, gameMap)
+	g.SearchView = nil
+	g.Screen = utils.PlayScreen
+	// ticks count from the start of the level, only the speed carries over
+	g.Clock = Clock{Speed: g.Clock.Speed}
+	g.onExit = false
+
+	loadBehaviourTrees(g)
+	gridMap.Subscribe(func(cells []*astar.Cell) { onMapChanged(g, cells) })
+	return nil
+}
+
+// checkMap makes sure gameMap has what a level is played on: the ground and
+// collision layers, covering the whole map, and the object groups with the
+// player's spawn point and the chickens'.
+func checkMap(gameMap *tiled.Map) error {
+	if gameMap.Width <= 0 || gameMap.Height <= 0 {
+		return fmt.Errorf("map is %dx%d tiles", gameMap.Width, gameMap.Height)
+	}
+	layers := []struct {
+		i    int
+		name string
+	}{{utils.GroundLayer, "ground"}, {utils.CollisionLayer, "collision"}}
+	for _, layer := range layers {
+		i, name := layer.i, layer.name
+		if i >= len(gameMap.Layers) {
+			return fmt.Errorf("no %s layer, want it as tile layer %d", name, i+1)
+		}
+		// infinite maps keep their tiles in chunks, which aren't loaded
+		if len(gameMap.Layers[i].Tiles) != gameMap.Width*gameMap.Height {
+			return fmt.Errorf("%s layer %q doesn't cover the map", name, gameMap.Layers[i].Name)
+		}
+	}
+	groups := []struct {
+		i    int
+		name string
+	}{{utils.PlayerSpawnPoint, "player spawn point"}, {utils.ChickenSpawnPoints, "chicken spawn points"}}
+	for _, group := range groups {
+		if group.i >= len(gameMap.ObjectGroups) {
+			return fmt.Errorf("no %s, want them in object group %d", group.name, group.i+1)
+		}
+	}
+	if len(gameMap.ObjectGroups[utils.PlayerSpawnPoint].Objects) == 0 {
+		return fmt.Errorf("object group %q has no player spawn point", gameMap.ObjectGroups[utils.PlayerSpawnPoint].Name)
+	}
+	return nil
+}
+
+// loadExits reads the zones of the Exits object group. An exit leads to the
+// level named by its "level" property, or to the next level without one.
+func loadExits(g *Game, gameMap *tiled.Map) []Exit {
+	exits := []Exit{}
+	for _, group := range gameMap.ObjectGroups {
+		if group.Name != utils.ExitsGroup {
+			continue
+		}
+		for _, object := range group.Objects {
+			exit := Exit{
+				Body: CollisionBody{
+					X:      int(object.X),
+					Y:      int(object.Y),
+					Width:  int(object.Width),
+					Height: int(object.Height),
+				},
+				Level: g.Level + 1,
+			}
+			if name := object.Properties.GetString("level"); name != "" {
+				exit.Level = -1
+				for i, level := range g.Levels {
+					if level.Name == name {
+						exit.Level = i
+					}
+				}
+			}
+			if exit.Level >= len(g.Levels) {
+				exit.Level = -1
+			}
+			exits = append(exits, exit)
+		}
+	}
+	return exits
+}
+
+// checkExits moves on to another level once the player walks into an exit.
+// A player still standing in one, e.g. back from the level select screen, has
+// to step out of it first.
+func checkExits(g *Game) {
+	wasOnExit := g.onExit
+	g.onExit = false
+	for _, exit := range g.Exits {
+		if !hasCollision(0, 0, g.Player.Collision, exit.Body) {
+			continue
+		}
+		g.onExit = true
+		if wasOnExit {
+			return
+		}
+		if exit.Level < 0 {
+			g.Screen = utils.LevelSelectScreen
+			return
+		}
+		if err := g.LoadLevel(exit.Level); err != nil {
+			fmt.Printf("error loading level: %s\n", err.Error())
+			g.Screen = utils.LevelSelectScreen
+		}
+		return
+	}
+}
+
+func updateLevelSelect(g *Game) {
+	selected := -1
+	for i := range g.Levels {
+		if i < 9 && g.Input.IsKeyJustPressed(input.DigitKey(i+1)) {
+			selected = i
+		}
+	}
+	if g.Input.IsMouseButtonJustPressed(input.MouseButtonLeft) {
+		mouseX, mouseY := g.Input.CursorPosition()
+		for i := range g.Levels {
+			if isClicked(mouseX, mouseY, LevelButton(i)) {
+				selected = i
+			}
+		}
+	}
+
+	if selected >= 0 {
+		if err := g.LoadLevel(selected); err != nil {
+			fmt.Printf("error loading level: %s\n", err.Error())
+		}
+	} else if g.Input.IsKeyJustPressed(input.KeyEscape) {
+		// back to the level that was being played
+		g.Screen = utils.PlayScreen
+	}
+}
+
+// LevelButton returns where level i is listed on the level select screen.
+func LevelButton(i int) CollisionBody {
+	return CollisionBody{
+		X:      40,
+		Y:      56 + i*24,
+		Width:  240,
+		Height: 16,
+	}
+}
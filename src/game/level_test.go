@@ -0,0 +1,167 @@
+package game
+
+import (
+	"a-star/src/input"
+	"a-star/src/utils"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/lafriks/go-tiled"
+)
+
+// newLevelsGame is a game with the levels of the assets, playing the first.
+func newLevelsGame(t *testing.T) *Game {
+	t.Helper()
+	assets := os.DirFS("../../assets")
+	levels, err := LoadLevels(assets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(levels) < 2 {
+		t.Fatalf("%d levels in the assets, want at least 2", len(levels))
+	}
+	g := NewGame(assets, assets, levels)
+	g.SetSeed(1)
+	if g.Screen != utils.LevelSelectScreen {
+		t.Fatal("game with more than one level doesn't start on the level select screen")
+	}
+	updateFrames(t, g, "1 press Digit1\n2 release Digit1\n", 2)
+	if g.Screen != utils.PlayScreen || g.Level != 0 {
+		t.Fatalf("on screen %d, level %d after picking the first level", g.Screen, g.Level)
+	}
+	return g
+}
+
+// updateFrames plays script for frames frames. Unlike a Runner, it leaves the
+// screen the game is on.
+func updateFrames(t *testing.T, g *Game, script string, frames int) {
+	t.Helper()
+	s, err := input.ParseScript(strings.NewReader(script))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.Input = s
+	for i := 0; i < frames; i++ {
+		if err := g.Update(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// walkIntoExit puts the player on exit and runs a tick.
+func walkIntoExit(g *Game, exit Exit) {
+	p := g.Player
+	p.Collision.X, p.Collision.Y = exit.Body.X, exit.Body.Y
+	p.Sprite.X, p.Sprite.Y = exit.Body.X, exit.Body.Y-15
+	g.tick()
+}
+
+func TestExitsLeadThroughTheLevels(t *testing.T) {
+	g := newLevelsGame(t)
+	g.Clock.Speed = 2
+	runTestGame(t, g, "", 30)
+	if g.Clock.Tick == 0 {
+		t.Fatal("first level didn't run")
+	}
+
+	// the farm's exit has no level, so it leads to the next one
+	if len(g.Exits) != 1 || g.Exits[0].Level != 1 {
+		t.Fatalf("farm exits %+v, want one to level 1", g.Exits)
+	}
+	walkIntoExit(g, g.Exits[0])
+	if g.Level != 1 || g.MapPath != g.Levels[1].Map || g.Screen != utils.PlayScreen {
+		t.Fatalf("walked into the exit and got to level %d on screen %d", g.Level, g.Screen)
+	}
+	if g.Clock.Tick != 0 || g.Clock.Speed != 2 {
+		t.Fatalf("next level starts at tick %d at speed %d, want 0 at 2", g.Clock.Tick, g.Clock.Speed)
+	}
+	spawn := g.GameMap.ObjectGroups[utils.PlayerSpawnPoint].Objects[0]
+	if g.Player.Sprite.X != int(spawn.X) || g.Player.Sprite.Y != int(spawn.Y) {
+		t.Fatal("player isn't on the spawn point of the next level")
+	}
+
+	// the maze's exit names the farm
+	var back *Exit
+	for i, exit := range g.Exits {
+		if exit.Level == 0 {
+			back = &g.Exits[i]
+		}
+	}
+	if back == nil {
+		t.Fatalf("maze exits %+v, none back to the farm", g.Exits)
+	}
+	runTestGame(t, g, "", 10)
+	walkIntoExit(g, *back)
+	if g.Level != 0 || g.Clock.Tick != 0 {
+		t.Fatalf("back at level %d, tick %d", g.Level, g.Clock.Tick)
+	}
+}
+
+func TestExitsToTheLevelSelect(t *testing.T) {
+	tests := []struct {
+		name string
+		exit Exit
+	}{
+		{"no level", Exit{Level: -1}},
+		{"level that doesn't load", Exit{Level: 1}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := newLevelsGame(t)
+			g.Levels[1].Map = "missing.tmx"
+			runTestGame(t, g, "", 10)
+			gameMap, tick := g.GameMap, g.Clock.Tick
+
+			exit := test.exit
+			exit.Body = g.Exits[0].Body
+			g.Exits = []Exit{exit}
+			walkIntoExit(g, exit)
+			if g.Screen != utils.LevelSelectScreen || g.Level != 0 || g.GameMap != gameMap {
+				t.Fatalf("on screen %d at level %d", g.Screen, g.Level)
+			}
+			// nothing runs on the level select screen, and escape goes back
+			// to the level that was being played
+			updateFrames(t, g, "", 10)
+			if g.Clock.Tick != tick+1 {
+				t.Fatalf("level ran to tick %d behind the level select screen", g.Clock.Tick)
+			}
+			updateFrames(t, g, "1 press Escape\n2 release Escape\n", 2)
+			if g.Screen != utils.PlayScreen || g.GameMap != gameMap {
+				t.Fatalf("escape went to screen %d rather than back to the level", g.Screen)
+			}
+		})
+	}
+}
+
+func TestLoadExits(t *testing.T) {
+	g := newLevelsGame(t)
+	var object *tiled.Object
+	for _, group := range g.GameMap.ObjectGroups {
+		if group.Name == utils.ExitsGroup {
+			object = group.Objects[0]
+		}
+	}
+	tests := []struct {
+		name  string
+		level string // "level" property of the exit, none if empty
+		from  int
+		want  int
+	}{
+		{"next level", "", 0, 1},
+		{"named level", "Farm", 1, 0},
+		{"unknown level", "Nowhere", 0, -1},
+		{"after the last level", "", len(g.Levels) - 1, -1},
+	}
+	for _, test := range tests {
+		object.Properties = nil
+		if test.level != "" {
+			object.Properties = tiled.Properties{{Name: "level", Value: test.level}}
+		}
+		g.Level = test.from
+		exits := loadExits(g, g.GameMap)
+		if len(exits) != 1 || exits[0].Level != test.want {
+			t.Errorf("%s: exits %+v, want one to level %d", test.name, exits, test.want)
+		}
+	}
+}
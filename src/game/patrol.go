@@ -0,0 +1,118 @@
+package game
+
+import (
+	"a-star/src/astar"
+	"a-star/src/utils"
+	"math/rand"
+	"sort"
+
+	"github.com/lafriks/go-tiled"
+)
+
+type PatrolRoute struct {
+	Name      string
+	Waypoints []*astar.Cell
+	Mode      int
+	Current   int
+	Reverse   bool // walking the route backwards in ping-pong mode
+}
+
+// LoadPatrolRoutes reads the waypoints of every route in the PatrolRoutes
+// object group. A polyline object is a route of its own, while point objects
+// sharing a name form a route ordered by their "order" property.
+func LoadPatrolRoutes(gameMap *tiled.Map, gridMap *astar.GridMap) map[string][]*astar.Cell {
+	routes := map[string][]*astar.Cell{}
+	for _, group := range gameMap.ObjectGroups {
+		if group.Name != utils.PatrolRoutesGroup {
+			continue
+		}
+
+		points := []*tiled.Object{}
+		for _, object := range group.Objects {
+			if len(object.PolyLines) == 0 {
+				points = append(points, object)
+				continue
+			}
+			for _, polyLine := range object.PolyLines {
+				if polyLine.Points == nil {
+					continue
+				}
+				for _, point := range *polyLine.Points {
+					// polyline points are relative to the object
+					cell := gridMap.GetGridCell(int(object.X+point.X)/gridMap.CellWidth, int(object.Y+point.Y)/gridMap.CellHeight)
+					if cell != nil {
+						routes[object.Name] = append(routes[object.Name], cell)
+					}
+				}
+			}
+		}
+
+		sort.SliceStable(points, func(i, j int) bool {
+			return points[i].Properties.GetInt("order") < points[j].Properties.GetInt("order")
+		})
+		for _, point := range points {
+			cell := gridMap.GetGridCell(int(point.X)/gridMap.CellWidth, int(point.Y)/gridMap.CellHeight)
+			if cell != nil {
+				routes[point.Name] = append(routes[point.Name], cell)
+			}
+		}
+	}
+	return routes
+}
+
+// NewPatrolRoute returns the route named by a spawn point's "patrol" and
+// "patrol_mode" properties, or nil if it has none.
+func NewPatrolRoute(spawnPoint *tiled.Object, routes map[string][]*astar.Cell) *PatrolRoute {
+	name := spawnPoint.Properties.GetString("patrol")
+	if name == "" || len(routes[name]) == 0 {
+		return nil
+	}
+
+	route := &PatrolRoute{Name: name, Waypoints: routes[name]}
+	switch spawnPoint.Properties.GetString("patrol_mode") {
+	case "pingpong":
+		route.Mode = utils.PatrolPingPong
+	case "random":
+		route.Mode = utils.PatrolRandom
+	default:
+		route.Mode = utils.PatrolLoop
+	}
+	return route
+}
+
+// Next returns the waypoint to head for and moves on to the one after it.
+func (r *PatrolRoute) Next(rng *rand.Rand) *astar.Cell {
+	waypoint := r.Waypoints[r.Current]
+	if len(r.Waypoints) == 1 {
+		return waypoint
+	}
+
+	switch r.Mode {
+	case utils.PatrolLoop:
+		r.Current = (r.Current + 1) % len(r.Waypoints)
+	case utils.PatrolPingPong:
+		if r.Current == len(r.Waypoints)-1 {
+			r.Reverse = true
+		} else if r.Current == 0 {
+			r.Reverse = false
+		}
+		if r.Reverse {
+			r.Current -= 1
+		} else {
+			r.Current += 1
+		}
+	case utils.PatrolRandom:
+		// any waypoint other than the one just visited
+		next := rng.Intn(len(r.Waypoints) - 1)
+		if next >= r.Current {
+			next += 1
+		}
+		r.Current = next
+	}
+	return waypoint
+}
+
+func (r *PatrolRoute) Restart() {
+	r.Current = 0
+	r.Reverse = false
+}
@@ -0,0 +1,101 @@
+package game
+
+import (
+	"a-star/src/astar"
+	"a-star/src/utils"
+	"math/rand"
+	"testing"
+)
+
+func TestLoadPatrolRoutes(t *testing.T) {
+	g := newTestGame(t, "map.tmx")
+	routes := LoadPatrolRoutes(g.GameMap, g.GridMap)
+
+	tests := []struct {
+		name      string
+		waypoints [][2]int
+	}{
+		// polylines, relative to their object
+		{"field", [][2]int{{22, 16}, {22, 9}, {15, 9}, {15, 16}}},
+		{"hallway", [][2]int{{5, 13}, {11, 13}, {11, 9}}},
+		// points, in "order" rather than in the file
+		{"coop", [][2]int{{28, 1}, {28, 18}, {20, 18}}},
+	}
+	if len(routes) != len(tests) {
+		t.Fatalf("%d routes, want %d", len(routes), len(tests))
+	}
+	for _, test := range tests {
+		route := routes[test.name]
+		if len(route) != len(test.waypoints) {
+			t.Fatalf("%s has %d waypoints, want %d", test.name, len(route), len(test.waypoints))
+		}
+		for i, cell := range route {
+			if cell.X != test.waypoints[i][0] || cell.Y != test.waypoints[i][1] {
+				t.Errorf("%s waypoint %d is %d,%d, want %v", test.name, i, cell.X, cell.Y, test.waypoints[i])
+			}
+		}
+	}
+
+	modes := map[string]int{"field": utils.PatrolLoop, "hallway": utils.PatrolPingPong, "coop": utils.PatrolRandom}
+	patrolling := 0
+	for _, c := range g.Chickens {
+		if c.Patrol == nil {
+			continue
+		}
+		patrolling += 1
+		if mode, ok := modes[c.Patrol.Name]; !ok || c.Patrol.Mode != mode {
+			t.Errorf("chicken patrols %q in mode %d", c.Patrol.Name, c.Patrol.Mode)
+		}
+	}
+	if patrolling != len(modes) {
+		t.Errorf("%d chickens on patrol, want %d", patrolling, len(modes))
+	}
+}
+
+func TestPatrolRouteNext(t *testing.T) {
+	waypoints := []*astar.Cell{astar.GetCell(0, 0), astar.GetCell(1, 0), astar.GetCell(2, 0)}
+	tests := []struct {
+		name  string
+		mode  int
+		cells []*astar.Cell
+		want  []int
+	}{
+		{"loop", utils.PatrolLoop, waypoints, []int{0, 1, 2, 0, 1, 2, 0}},
+		{"ping-pong", utils.PatrolPingPong, waypoints, []int{0, 1, 2, 1, 0, 1, 2}},
+		{"one waypoint", utils.PatrolPingPong, waypoints[:1], []int{0, 0, 0}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			route := &PatrolRoute{Waypoints: test.cells, Mode: test.mode}
+			for i, want := range test.want {
+				if got := route.Next(nil); got != test.cells[want] {
+					t.Fatalf("waypoint %d is %d,%d, want %d", i, got.X, got.Y, want)
+				}
+			}
+
+			route.Restart()
+			if got := route.Next(nil); got != test.cells[0] {
+				t.Fatal("restarted route doesn't start at the first waypoint")
+			}
+		})
+	}
+}
+
+func TestPatrolRouteRandomNeverStays(t *testing.T) {
+	waypoints := []*astar.Cell{astar.GetCell(0, 0), astar.GetCell(1, 0), astar.GetCell(2, 0)}
+	route := &PatrolRoute{Waypoints: waypoints, Mode: utils.PatrolRandom}
+	rng := rand.New(rand.NewSource(1))
+	visited := map[*astar.Cell]bool{}
+	last := route.Next(rng)
+	for i := 0; i < 100; i++ {
+		next := route.Next(rng)
+		if next == last {
+			t.Fatalf("route stayed at %d,%d", next.X, next.Y)
+		}
+		visited[next] = true
+		last = next
+	}
+	if len(visited) != len(waypoints) {
+		t.Fatalf("visited %d of %d waypoints", len(visited), len(waypoints))
+	}
+}
@@ -0,0 +1,236 @@
+package game
+
+import (
+	"a-star/src/input"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"os"
+	"sort"
+	"time"
+)
+
+// Recording is everything needed to play a session again exactly as it went:
+// the random seed, where the game was at the start, every input event and the
+// size of the screen. A checksum of the game state after every tick lets a
+// replay tell whether it still matches.
+type Recording struct {
+	Seed      int64         `json:"seed"`
+	Level     int           `json:"level"`
+	Screen    int           `json:"screen"`
+	Frames    int           `json:"frames"`
+	Events    []input.Event `json:"events"`
+	Layouts   []Layout      `json:"layouts"`
+	Checksums []uint64      `json:"checksums"`       // after each tick, starting with the first one recorded
+	Loads     []*SaveState  `json:"loads,omitempty"` // games loaded while recording, nil for those that couldn't be read
+	recorder  *input.Recorder
+}
+
+// Layout is a change of screen size, which matters because the camera turns
+// mouse positions into map cells.
+type Layout struct {
+	Frame  int `json:"frame"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+// StartRecording reseeds g and records the input of its window from now on.
+// It has to be called before the first frame.
+func StartRecording(g *Game) (*Recording, error) {
+	if g.Device == nil {
+		return nil, fmt.Errorf("only games played in a window can be recorded")
+	}
+	r := &Recording{
+		Seed:     time.Now().UnixNano(),
+		Level:    g.Level,
+		Screen:   g.Screen,
+		recorder: input.NewRecorder(g.Device),
+	}
+	g.SetSeed(r.Seed)
+	g.Input = r.recorder
+	g.Recording = r
+	return r, nil
+}
+
+// Save writes the recording to name as JSON.
+func (r *Recording) Save(name string) error {
+	if r.recorder != nil {
+		r.Frames = r.recorder.Frame()
+		r.Events = r.recorder.Events()
+	}
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(name, data, 0644)
+}
+
+func LoadRecording(name string) (*Recording, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	r := &Recording{}
+	if err := json.Unmarshal(data, r); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return r, nil
+}
+
+// layout records the screen size for the coming frame.
+func (r *Recording) layout(width, height int) {
+	frame := r.recorder.Frame() + 1
+	if n := len(r.Layouts); n > 0 && r.Layouts[n-1].Width == width && r.Layouts[n-1].Height == height {
+		return
+	}
+	r.Layouts = append(r.Layouts, Layout{Frame: frame, Width: width, Height: height})
+}
+
+// Replay plays a recording back into a game and checks each tick against it.
+type Replay struct {
+	Recording *Recording
+	Script    *input.Script
+	Diverged  int // first tick whose state didn't match the recording, 0 if none
+	ticks     int // ticks played, the clock restarts on every level
+	loads     int // games loaded from the recording
+}
+
+// StartReplay puts g back where the recording started and makes it read its
+// input from the recording. g has to be fresh from NewGame with the same
+// levels as the recorded game.
+func StartReplay(g *Game, r *Recording) (*Replay, error) {
+	if r.Level != g.Level {
+		if err := g.LoadLevel(r.Level); err != nil {
+			return nil, err
+		}
+	}
+	g.Screen = r.Screen
+	g.SetSeed(r.Seed)
+
+	replay := &Replay{Recording: r, Script: input.NewScript(r.Events)}
+	g.Input = replay.Script
+	g.Replay = replay
+	return replay, nil
+}
+
+// Finished reports whether every recorded frame has been played.
+func (r *Replay) Finished() bool {
+	return r.Script.Frame() >= r.Recording.Frames
+}
+
+// endReplay hands the game back to the keyboard and mouse once the recording
+// is over, if it has any.
+func endReplay(g *Game) {
+	if g.Replay.Diverged == 0 {
+		fmt.Printf("replay matched the recording for all %d ticks\n", len(g.Replay.Recording.Checksums))
+	}
+	g.Replay = nil
+	if g.Device != nil {
+		g.Input = g.Device
+	} else {
+		g.Input = input.NewScript(nil)
+	}
+}
+
+// replaying reports whether a recording is being played back, saying that
+// what is skipped if so. Files written during the recorded session, saved
+// games, maps, exports and captures, aren't written again by the replay.
+func replaying(g *Game, what string) bool {
+	if g.Replay == nil {
+		return false
+	}
+	fmt.Printf("not %s while replaying a recording\n", what)
+	return true
+}
+
+// screenSize returns the size the screen had on the coming frame.
+func (r *Replay) screenSize(width, height int) (int, int) {
+	frame := r.Script.Frame() + 1
+	for _, layout := range r.Recording.Layouts {
+		if layout.Frame > frame {
+			break
+		}
+		width, height = layout.Width, layout.Height
+	}
+	return width, height
+}
+
+// checkTick compares the state after a tick with the recording.
+func (r *Replay) checkTick(g *Game) {
+	r.ticks += 1
+	tick := r.ticks
+	if r.Diverged != 0 || tick > len(r.Recording.Checksums) {
+		return
+	}
+	if r.Recording.Checksums[tick-1] != Checksum(g) {
+		r.Diverged = tick
+		fmt.Printf("replay diverged from the recording at tick %d\n", tick)
+	}
+}
+
+// nextLoad returns the game loaded at this point of the recording, rather
+// than whatever the save file holds now.
+func (r *Replay) nextLoad() (*SaveState, error) {
+	if r.loads >= len(r.Recording.Loads) {
+		return nil, fmt.Errorf("the recording loaded only %d games", len(r.Recording.Loads))
+	}
+	r.loads += 1
+	if s := r.Recording.Loads[r.loads-1]; s != nil {
+		return s, nil
+	}
+	return nil, fmt.Errorf("the recorded game couldn't be read either")
+}
+
+// afterTick records or checks the state after every tick.
+func afterTick(g *Game) {
+	if g.Recording != nil {
+		g.Recording.Checksums = append(g.Recording.Checksums, Checksum(g))
+	}
+	if g.Replay != nil {
+		g.Replay.checkTick(g)
+	}
+}
+
+// Checksum hashes the state of the simulation: the level, the random numbers,
+// the player, the chickens with their paths and behaviour, the fences and
+// every cell of the map, as fenced and edited.
+func Checksum(g *Game) uint64 {
+	values := []int64{int64(g.Level), int64(g.Clock.Tick), g.random.seed, g.random.draws}
+	p := g.Player
+	values = append(values, int64(p.XLoc), int64(p.YLoc), int64(p.Dx), int64(p.Dy),
+		int64(p.State), int64(p.StateTTL), int64(p.Direction), int64(p.Frame))
+	for _, c := range g.Chickens {
+		values = append(values, int64(c.XLoc), int64(c.YLoc), int64(c.SteerX), int64(c.SteerY),
+			int64(c.State), int64(c.StateTTL), int64(c.Direction), int64(c.Frame),
+			int64(c.Behaviour), int64(c.BehaviourTTL))
+		if c.Path != nil {
+			values = append(values, int64(c.Path.CurrentCell), int64(len(c.Path.Cells)))
+			for _, cell := range c.Path.Cells {
+				values = append(values, int64(cell.X), int64(cell.Y))
+			}
+		}
+	}
+
+	// fences are kept in a map, so sort them first
+	fences := []int64{}
+	for cell := range g.Fences {
+		fences = append(fences, int64(cell.Y*g.GridMap.Width+cell.X))
+	}
+	sort.Slice(fences, func(i, j int) bool { return fences[i] < fences[j] })
+	values = append(values, fences...)
+	for y := range g.GridMap.Cells {
+		for _, cell := range g.GridMap.Cells[y] {
+			walkable := int64(0)
+			if cell.IsWalkable {
+				walkable = 1
+			}
+			values = append(values, walkable, int64(math.Float64bits(cell.Cost)))
+		}
+	}
+
+	hash := fnv.New64a()
+	binary.Write(hash, binary.LittleEndian, values)
+	return hash.Sum64()
+}
@@ -0,0 +1,161 @@
+package game
+
+import (
+	"a-star/src/input"
+	"a-star/src/utils"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReplayWritesNoFiles(t *testing.T) {
+	g := newTestGame(t, "map.tmx")
+	if _, err := StartReplay(g, &Recording{Seed: 1, Level: g.Level, Screen: g.Screen, Frames: 10}); err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmp := t.TempDir()
+	if err := os.Chdir(tmp); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(dir)
+	g.Editor.SavePath = "edited.tmx"
+
+	saveGame(g)
+	saveEdits(g)
+	exportSearch(g, g.Player.XLoc, g.Player.YLoc)
+	toggleCapture(g)
+	if g.Capture != nil {
+		t.Fatal("screen captured during a replay")
+	}
+
+	files, err := os.ReadDir(tmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		t.Errorf("replay wrote %s", file.Name())
+	}
+}
+
+// scriptDevice plays a script as if it were the keyboard and mouse of a
+// window, so it can be recorded.
+type scriptDevice struct {
+	*input.Script
+}
+
+func (d scriptDevice) PressedKeys() []input.Key {
+	keys := []input.Key{}
+	for _, key := range []input.Key{input.KeyF, input.KeyF9, input.KeyD} {
+		if d.IsKeyPressed(key) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// playFrames runs frames frames the way Ebiten would, advancing the window's
+// keyboard and mouse if there is one.
+func playFrames(t *testing.T, g *Game, frames int) {
+	t.Helper()
+	for i := 0; i < frames; i++ {
+		if g.Device != nil {
+			g.Device.Advance()
+		}
+		g.Layout(320, 240)
+		if err := g.Update(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestReplayLoadsTheRecordedGame(t *testing.T) {
+	dir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmp := t.TempDir()
+	if err := os.Chdir(tmp); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(dir)
+	assets := os.DirFS(filepath.Join(dir, "../../assets"))
+	newGame := func() *Game {
+		g := NewGame(assets, assets, []Level{{Name: "map.tmx", Map: "map.tmx"}})
+		g.SetSeed(1)
+		return g
+	}
+
+	saved := newGame()
+	runTestGame(t, saved, "1 press D\n", 50)
+	if err := saved.SaveGame(utils.SaveGamePath); err != nil {
+		t.Fatal(err)
+	}
+
+	// load the saved game, then walk and fence a cell next to the player
+	script, err := input.ParseScript(strings.NewReader("10 press F9\n11 release F9\n20 move 176 180\n20 press F\n21 release F\n30 press D\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := newGame()
+	g.Device = scriptDevice{script}
+	recording, err := StartRecording(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	playFrames(t, g, 60)
+	if len(recording.Loads) != 1 || len(g.Fences) != 1 {
+		t.Fatalf("recorded %d loads and %d fences, want 1 of each", len(recording.Loads), len(g.Fences))
+	}
+	if err := recording.Save("run.json"); err != nil {
+		t.Fatal(err)
+	}
+	recording, err = LoadRecording("run.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the save file has moved on since, the replay doesn't read it
+	runTestGame(t, saved, "", 50)
+	if err := saved.SaveGame(utils.SaveGamePath); err != nil {
+		t.Fatal(err)
+	}
+
+	replayed := newGame()
+	replay, err := StartReplay(replayed, recording)
+	if err != nil {
+		t.Fatal(err)
+	}
+	playFrames(t, replayed, recording.Frames)
+	if replay.Diverged != 0 || replay.ticks != len(recording.Checksums) {
+		t.Fatalf("replay diverged at tick %d after %d of %d ticks", replay.Diverged, replay.ticks, len(recording.Checksums))
+	}
+	if Checksum(replayed) != Checksum(g) {
+		t.Fatal("replay ended somewhere else than the recording")
+	}
+}
+
+func TestChecksumCoversMapAndRandomNumbers(t *testing.T) {
+	g := newTestGame(t, "map.tmx")
+	sum := Checksum(g)
+
+	cell := g.GridMap.GetGridCell(18, 11)
+	g.GridMap.SetCell(cell.X, cell.Y, true, cell.Cost+1)
+	if Checksum(g) == sum {
+		t.Fatal("checksum missed an edited cell")
+	}
+	g.GridMap.SetCell(cell.X, cell.Y, true, cell.Cost-1)
+	if Checksum(g) != sum {
+		t.Fatal("checksum changed with the cell back as it was")
+	}
+
+	g.Rand.Intn(10)
+	if Checksum(g) == sum {
+		t.Fatal("checksum missed a random number drawn")
+	}
+}
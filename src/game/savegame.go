@@ -0,0 +1,401 @@
+package game
+
+import (
+	"a-star/src/astar"
+	"a-star/src/bt"
+	"a-star/src/utils"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/lafriks/go-tiled"
+)
+
+// SaveState is a snapshot of a game that can be written to JSON and loaded
+// back later. Chickens driven by a behaviour tree keep where they were in it,
+// as long as the tree file hasn't changed since; otherwise they start it over
+// from wherever they were.
+type SaveState struct {
+	Level     int            `json:"level"`
+	Tick      int            `json:"tick"`
+	Paused    bool           `json:"paused"`
+	Speed     int            `json:"speed"`
+	Seed      int64          `json:"seed"`  // seed of the random numbers
+	Draws     int64          `json:"draws"` // random numbers drawn since seeding, so loading continues the same way
+	Algorithm int            `json:"algorithm"`
+	Heuristic int            `json:"heuristic"`
+	Zoom      float64        `json:"zoom"`
+	Player    CharacterState `json:"player"`
+	Chickens  []ChickenState `json:"chickens"`
+	Layers    [][]uint32     `json:"layers"` // gid of every tile of every layer, as edited
+	Fences    [][2]int       `json:"fences"` // x, y of each fence
+}
+
+type CharacterState struct {
+	XLoc      int           `json:"x"`
+	YLoc      int           `json:"y"`
+	Dx        int           `json:"dx"`
+	Dy        int           `json:"dy"`
+	State     int           `json:"state"`
+	StateTTL  int           `json:"stateTTL"`
+	Direction int           `json:"direction"`
+	Frame     int           `json:"frame"`
+	Sprite    CollisionBody `json:"sprite"`
+	Collision CollisionBody `json:"collision"`
+}
+
+type ChickenState struct {
+	CharacterState
+	SteerX          int          `json:"steerX"`
+	SteerY          int          `json:"steerY"`
+	Path            *PathState   `json:"path,omitempty"`
+	Patrol          *PatrolState `json:"patrol,omitempty"`
+	Behaviour       int          `json:"behaviour"`
+	BehaviourTTL    int          `json:"behaviourTTL"`
+	BehaviourForced bool         `json:"behaviourForced"`
+	Tree            *bt.State    `json:"tree,omitempty"`
+}
+
+type PathState struct {
+	Cells       [][2]int `json:"cells"`
+	CurrentCell int      `json:"currentCell"`
+}
+
+type PatrolState struct {
+	Current int  `json:"current"`
+	Reverse bool `json:"reverse"`
+}
+
+// SaveGame writes the state of g to name.
+func (g *Game) SaveGame(name string) error {
+	data, err := json.Marshal(NewSaveState(g))
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(name, data, 0644)
+}
+
+// LoadGame replaces the state of g with the one saved in name.
+func (g *Game) LoadGame(name string) error {
+	s, err := readSaveState(name)
+	if err != nil {
+		return err
+	}
+	if err := s.Restore(g); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
+
+func readSaveState(name string) (*SaveState, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	s := &SaveState{}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return s, nil
+}
+
+func saveGame(g *Game) {
+	if replaying(g, "saving the game") {
+		return
+	}
+	if err := g.SaveGame(utils.SaveGamePath); err != nil {
+		fmt.Printf("error saving game: %s\n", err.Error())
+	} else {
+		fmt.Printf("saved game to %s\n", utils.SaveGamePath)
+	}
+}
+
+// loadGame loads the saved game. A recording keeps what was loaded, since the
+// save file may have changed by the time it is replayed, and a replay loads
+// that instead.
+func loadGame(g *Game) {
+	var s *SaveState
+	var err error
+	if g.Replay != nil {
+		s, err = g.Replay.nextLoad()
+	} else {
+		s, err = readSaveState(utils.SaveGamePath)
+		if g.Recording != nil {
+			// nil if it couldn't be read, so the replay fails to load it too
+			g.Recording.Loads = append(g.Recording.Loads, s)
+		}
+	}
+	if err == nil {
+		err = s.Restore(g)
+	}
+	if err != nil {
+		fmt.Printf("error loading game: %s\n", err.Error())
+		return
+	}
+	fmt.Printf("loaded game from %s\n", utils.SaveGamePath)
+}
+
+// NewSaveState takes a snapshot of g, leaving g as it was.
+func NewSaveState(g *Game) *SaveState {
+	s := &SaveState{
+		Level:     g.Level,
+		Tick:      g.Clock.Tick,
+		Paused:    g.Clock.Paused,
+		Speed:     g.Clock.Speed,
+		Seed:      g.random.seed,
+		Draws:     g.random.draws,
+		Algorithm: g.Algorithm,
+		Heuristic: g.Heuristic,
+		Zoom:      g.Camera.Zoom,
+		Player:    characterState(g.Player.XLoc, g.Player.YLoc, g.Player.Dx, g.Player.Dy, g.Player.State, g.Player.StateTTL, g.Player.Direction, g.Player.Frame, g.Player.Sprite, g.Player.Collision),
+	}
+
+	for _, c := range g.Chickens {
+		chicken := ChickenState{
+			CharacterState:  characterState(c.XLoc, c.YLoc, c.Dx, c.Dy, c.State, c.StateTTL, c.Direction, c.Frame, c.Sprite, c.Collision),
+			SteerX:          c.SteerX,
+			SteerY:          c.SteerY,
+			Behaviour:       c.Behaviour,
+			BehaviourTTL:    c.BehaviourTTL,
+			BehaviourForced: c.BehaviourForced,
+		}
+		if c.Path != nil {
+			chicken.Path = &PathState{CurrentCell: c.Path.CurrentCell}
+			for _, cell := range c.Path.Cells {
+				chicken.Path.Cells = append(chicken.Path.Cells, [2]int{cell.X, cell.Y})
+			}
+		}
+		if c.Patrol != nil {
+			chicken.Patrol = &PatrolState{Current: c.Patrol.Current, Reverse: c.Patrol.Reverse}
+		}
+		if c.Tree != nil {
+			chicken.Tree = bt.SaveState(c.Tree)
+		}
+		s.Chickens = append(s.Chickens, chicken)
+	}
+
+	for _, layer := range g.GameMap.Layers {
+		gids := []uint32{}
+		for _, tile := range layer.Tiles {
+			gids = append(gids, tileGID(tile))
+		}
+		s.Layers = append(s.Layers, gids)
+	}
+	for cell := range g.Fences {
+		s.Fences = append(s.Fences, [2]int{cell.X, cell.Y})
+	}
+	// fences are kept in a map, so sort them to save the same file every time
+	sort.Slice(s.Fences, func(i, j int) bool {
+		a, b := s.Fences[i], s.Fences[j]
+		return a[1] < b[1] || (a[1] == b[1] && a[0] < b[0])
+	})
+	return s
+}
+
+func characterState(x, y, dx, dy, state, stateTTL, direction, frame int, sprite, collision CollisionBody) CharacterState {
+	return CharacterState{
+		XLoc:      x,
+		YLoc:      y,
+		Dx:        dx,
+		Dy:        dy,
+		State:     state,
+		StateTTL:  stateTTL,
+		Direction: direction,
+		Frame:     frame,
+		Sprite:    sprite,
+		Collision: collision,
+	}
+}
+
+// Restore loads the level of the snapshot into g and puts everything back
+// where it was. The whole snapshot is checked against the level first, so g
+// is left as it was if it doesn't fit.
+func (s *SaveState) Restore(g *Game) error {
+	if s.Level < 0 || s.Level >= len(g.Levels) {
+		return fmt.Errorf("no level %d", s.Level)
+	}
+	level := g.Levels[s.Level]
+	gameMap, err := loadMap(g.MapFS, level.Map)
+	if err != nil {
+		return err
+	}
+	if err := s.validate(gameMap); err != nil {
+		return fmt.Errorf("saved game doesn't match level %s: %w", level.Name, err)
+	}
+	if err := g.startLevel(s.Level, gameMap); err != nil {
+		return err
+	}
+
+	// the map comes first, changing it replans the paths of chickens
+	restoreTiles(g, s.Layers)
+	for _, location := range s.Fences {
+		cell := g.GridMap.GetGridCell(location[0], location[1])
+		fence := &astar.Obstacle{Cells: []*astar.Cell{cell}}
+		g.Fences[cell] = fence
+		g.GridMap.AddObstacle(fence)
+	}
+
+	g.Clock = Clock{Tick: s.Tick, Paused: s.Paused, Speed: s.Speed}
+	g.setRandState(s.Seed, s.Draws)
+	g.Algorithm = s.Algorithm
+	g.Heuristic = s.Heuristic
+	g.Camera.Zoom = s.Zoom
+
+	p := g.Player
+	p.XLoc, p.YLoc, p.Dx, p.Dy = s.Player.XLoc, s.Player.YLoc, s.Player.Dx, s.Player.Dy
+	p.State, p.StateTTL, p.Direction, p.Frame = s.Player.State, s.Player.StateTTL, s.Player.Direction, s.Player.Frame
+	p.Sprite, p.Collision = s.Player.Sprite, s.Player.Collision
+
+	for i, saved := range s.Chickens {
+		c := g.Chickens[i]
+		c.XLoc, c.YLoc, c.Dx, c.Dy = saved.XLoc, saved.YLoc, saved.Dx, saved.Dy
+		c.State, c.StateTTL, c.Direction, c.Frame = saved.State, saved.StateTTL, saved.Direction, saved.Frame
+		c.Sprite, c.Collision = saved.Sprite, saved.Collision
+		c.SteerX, c.SteerY = saved.SteerX, saved.SteerY
+		c.Behaviour, c.BehaviourTTL, c.BehaviourForced = saved.Behaviour, saved.BehaviourTTL, saved.BehaviourForced
+
+		c.Path = nil
+		if saved.Path != nil {
+			c.Path = &astar.Path{CurrentCell: saved.Path.CurrentCell}
+			for _, location := range saved.Path.Cells {
+				c.Path.Cells = append(c.Path.Cells, g.GridMap.GetGridCell(location[0], location[1]))
+			}
+		}
+		if c.Patrol != nil && saved.Patrol != nil {
+			c.Patrol.Current, c.Patrol.Reverse = saved.Patrol.Current, saved.Patrol.Reverse
+		}
+		if c.Tree != nil {
+			if err := bt.RestoreState(c.Tree, saved.Tree); err != nil {
+				fmt.Printf("chicken %d starts its behaviour tree over: %s\n", i, err.Error())
+			}
+		}
+	}
+	return nil
+}
+
+// validate checks that the snapshot fits gameMap, freshly loaded, and that
+// every index in it is in range.
+func (s *SaveState) validate(gameMap *tiled.Map) error {
+	if s.Tick < 0 || s.Draws < 0 {
+		return fmt.Errorf("negative tick %d or draws %d", s.Tick, s.Draws)
+	}
+	if s.Speed < 0 || s.Speed >= len(utils.SimulationSpeeds) {
+		return fmt.Errorf("no speed %d", s.Speed)
+	}
+	if s.Algorithm < 0 || s.Algorithm >= len(astar.Algorithms) {
+		return fmt.Errorf("no algorithm %d", s.Algorithm)
+	}
+	if s.Heuristic < 0 || s.Heuristic >= len(astar.Heuristics) {
+		return fmt.Errorf("no heuristic %d", s.Heuristic)
+	}
+	// written so that NaN fails too
+	if !(s.Zoom >= utils.CameraMinZoom && s.Zoom <= utils.CameraMaxZoom) {
+		return fmt.Errorf("zoom %g isn't between %g and %g", s.Zoom, utils.CameraMinZoom, float64(utils.CameraMaxZoom))
+	}
+
+	if len(s.Layers) != len(gameMap.Layers) {
+		return fmt.Errorf("%d layers, not %d", len(s.Layers), len(gameMap.Layers))
+	}
+	for i, gids := range s.Layers {
+		tiles := gameMap.Layers[i].Tiles
+		if len(gids) != len(tiles) {
+			return fmt.Errorf("layer %s has %d tiles, not %d", gameMap.Layers[i].Name, len(tiles), len(gids))
+		}
+		for j, gid := range gids {
+			if tileGID(tiles[j]) == gid {
+				continue
+			}
+			tile, err := gameMap.TileGIDToTile(gid)
+			if err != nil {
+				return err
+			}
+			if int(tile.ID) >= tile.Tileset.TileCount {
+				return fmt.Errorf("no tile %d in tileset %s", tile.ID, tile.Tileset.Name)
+			}
+		}
+	}
+	inMap := func(location [2]int) bool {
+		return location[0] >= 0 && location[0] < gameMap.Width && location[1] >= 0 && location[1] < gameMap.Height
+	}
+	for _, location := range s.Fences {
+		if !inMap(location) {
+			return fmt.Errorf("fence outside the map at %d,%d", location[0], location[1])
+		}
+	}
+
+	if err := s.Player.validate(utils.NumOfDirections, utils.PlayerFrameCount, utils.IdleState, utils.WalkState); err != nil {
+		return fmt.Errorf("player: %w", err)
+	}
+	spawnPoints := gameMap.ObjectGroups[utils.ChickenSpawnPoints].Objects
+	if len(s.Chickens) != len(spawnPoints) {
+		return fmt.Errorf("%d chickens, not %d", len(s.Chickens), len(spawnPoints))
+	}
+	routes := LoadPatrolRoutes(gameMap, astar.NewGridMap(gameMap))
+	for i, saved := range s.Chickens {
+		if err := saved.validate(utils.ChickenNumOfDirections, utils.ChickenFrameCount, utils.ChickenIdleState, utils.ChickenWalkState); err != nil {
+			return fmt.Errorf("chicken %d: %w", i, err)
+		}
+		if saved.Behaviour < utils.BehaviourRest || saved.Behaviour > utils.BehaviourFlee {
+			return fmt.Errorf("chicken %d: no behaviour %d", i, saved.Behaviour)
+		}
+		if saved.Path != nil {
+			for _, location := range saved.Path.Cells {
+				if !inMap(location) {
+					return fmt.Errorf("chicken %d has a path outside the map at %d,%d", i, location[0], location[1])
+				}
+			}
+			// a path is done once its current cell is one past the last
+			if saved.Path.CurrentCell < 0 || saved.Path.CurrentCell > len(saved.Path.Cells) {
+				return fmt.Errorf("chicken %d is on cell %d of a path of %d", i, saved.Path.CurrentCell, len(saved.Path.Cells))
+			}
+		}
+		route := NewPatrolRoute(spawnPoints[i], routes)
+		if route != nil && saved.Patrol != nil && (saved.Patrol.Current < 0 || saved.Patrol.Current >= len(route.Waypoints)) {
+			return fmt.Errorf("chicken %d is on waypoint %d of a route of %d", i, saved.Patrol.Current, len(route.Waypoints))
+		}
+	}
+	return nil
+}
+
+// validate checks the indexes of a character's sprite sheet, it has the given
+// number of directions and frames and is in one of states.
+func (c CharacterState) validate(directions, frames int, states ...int) error {
+	if c.Direction < 0 || c.Direction >= directions {
+		return fmt.Errorf("no direction %d", c.Direction)
+	}
+	if c.Frame < 0 || c.Frame >= frames {
+		return fmt.Errorf("no frame %d", c.Frame)
+	}
+	for _, state := range states {
+		if c.State == state {
+			return nil
+		}
+	}
+	return fmt.Errorf("no state %d", c.State)
+}
+
+// restoreTiles puts the saved tiles into the map, updating the grid map for
+// every cell that was edited. The gids have been validated already.
+func restoreTiles(g *Game, layers [][]uint32) {
+	changed := map[int]bool{}
+	for i, gids := range layers {
+		tiles := g.GameMap.Layers[i].Tiles
+		for j, gid := range gids {
+			if tileGID(tiles[j]) == gid {
+				continue
+			}
+			tile, _ := g.GameMap.TileGIDToTile(gid)
+			tiles[j] = copyTile(tile)
+			changed[j] = true
+		}
+	}
+
+	ground := g.GameMap.Layers[utils.GroundLayer].Tiles
+	walls := g.GameMap.Layers[utils.CollisionLayer].Tiles
+	for i := range changed {
+		x, y := i%g.GameMap.Width, i/g.GameMap.Width
+		g.GridMap.SetCell(x, y, walls[i].IsNil(), astar.TileCost(ground[i]))
+		tilesChanged(g, x, y)
+	}
+}
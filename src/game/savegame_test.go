@@ -0,0 +1,199 @@
+package game
+
+import (
+	"a-star/src/input"
+	"a-star/src/utils"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// runTestGame plays script on g for frames frames.
+func runTestGame(t *testing.T, g *Game, script string, frames int) {
+	t.Helper()
+	s, err := input.ParseScript(strings.NewReader(script))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := NewRunner(g, s).RunUntil(frames, func(g *Game) bool { return false }); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNewSaveStateLeavesGameAlone(t *testing.T) {
+	saved, unsaved := newTestGame(t, "map.tmx"), newTestGame(t, "map.tmx")
+	runTestGame(t, saved, "", 200)
+	runTestGame(t, unsaved, "", 200)
+	NewSaveState(saved)
+	runTestGame(t, saved, "", 400)
+	runTestGame(t, unsaved, "", 400)
+	if Checksum(saved) != Checksum(unsaved) {
+		t.Fatal("saving the game changed how it went on")
+	}
+}
+
+func TestSaveStateRoundTrip(t *testing.T) {
+	for _, name := range []string{"map.tmx", "maze.tmx"} {
+		t.Run(name, func(t *testing.T) {
+			g := newTestGame(t, name)
+			runTestGame(t, g, "", 200)
+			data, err := json.Marshal(NewSaveState(g))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			loaded := newTestGame(t, name)
+			loaded.SetSeed(2)
+			s := &SaveState{}
+			if err := json.Unmarshal(data, s); err != nil {
+				t.Fatal(err)
+			}
+			if err := s.Restore(loaded); err != nil {
+				t.Fatal(err)
+			}
+			if Checksum(loaded) != Checksum(g) {
+				t.Fatal("loaded game differs from the saved one")
+			}
+			if a, b := loaded.Rand.Int63(), g.Rand.Int63(); a != b {
+				t.Fatalf("loaded game draws %d, saved one %d", a, b)
+			}
+
+			runTestGame(t, g, "", 300)
+			runTestGame(t, loaded, "", 300)
+			if Checksum(loaded) != Checksum(g) {
+				t.Fatal("loaded game went on differently from the saved one")
+			}
+		})
+	}
+}
+
+func TestRestoreRejectsBadStates(t *testing.T) {
+	g := newTestGame(t, "map.tmx")
+	patrolling := -1
+	for i, c := range g.Chickens {
+		if c.Patrol != nil {
+			patrolling = i
+		}
+	}
+	if patrolling < 0 {
+		t.Fatal("no chicken on patrol on the map")
+	}
+
+	tests := []struct {
+		name   string
+		change func(s *SaveState)
+	}{
+		{"level", func(s *SaveState) { s.Level = 1 }},
+		{"speed", func(s *SaveState) { s.Speed = 4 }},
+		{"algorithm", func(s *SaveState) { s.Algorithm = -1 }},
+		{"heuristic", func(s *SaveState) { s.Heuristic = 100 }},
+		{"zoom", func(s *SaveState) { s.Zoom = 0 }},
+		{"draws", func(s *SaveState) { s.Draws = -1 }},
+		{"chickens", func(s *SaveState) { s.Chickens = s.Chickens[1:] }},
+		{"layers", func(s *SaveState) { s.Layers = s.Layers[1:] }},
+		{"tiles", func(s *SaveState) { s.Layers[0] = s.Layers[0][1:] }},
+		{"gid", func(s *SaveState) { s.Layers[0][0] = 10000 }},
+		{"fence", func(s *SaveState) { s.Fences = append(s.Fences, [2]int{-1, 0}) }},
+		{"direction", func(s *SaveState) { s.Player.Direction = 4 }},
+		{"frame", func(s *SaveState) { s.Chickens[0].Frame = -1 }},
+		{"state", func(s *SaveState) { s.Chickens[0].State = 1 }},
+		{"behaviour", func(s *SaveState) { s.Chickens[0].Behaviour = 9 }},
+		{"path cell", func(s *SaveState) { s.Chickens[0].Path = &PathState{Cells: [][2]int{{0, 1000}}} }},
+		{"current cell", func(s *SaveState) { s.Chickens[0].Path = &PathState{Cells: [][2]int{{1, 1}}, CurrentCell: 2} }},
+		{"waypoint", func(s *SaveState) { s.Chickens[patrolling].Patrol = &PatrolState{Current: 100} }},
+	}
+
+	runTestGame(t, g, "1 click 8 8\n", 100)
+	data, err := json.Marshal(NewSaveState(g))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := &SaveState{}
+			if err := json.Unmarshal(data, s); err != nil {
+				t.Fatal(err)
+			}
+			test.change(s)
+			before, gameMap := Checksum(g), g.GameMap
+			if err := s.Restore(g); err == nil {
+				t.Fatal("bad state restored")
+			}
+			if Checksum(g) != before || g.GameMap != gameMap {
+				t.Fatal("bad state changed the game")
+			}
+		})
+	}
+}
+
+func TestSaveStateKeepsTreeChickensOnTheirPath(t *testing.T) {
+	g := newTestGame(t, "map.tmx")
+	i := -1
+	for j, c := range g.Chickens {
+		if c.Tree != nil {
+			i = j
+		}
+	}
+	if i < 0 {
+		t.Fatal("no chicken with a behaviour tree on the map")
+	}
+	c := g.Chickens[i]
+	for frame := 0; frame < 600; frame++ {
+		if c.Path != nil && c.Path.CurrentCell > 0 && c.Path.CurrentCell < len(c.Path.Cells)-1 && !c.IsOnCell() {
+			break
+		}
+		runTestGame(t, g, "", 1)
+	}
+	if c.Path == nil || c.IsOnCell() {
+		t.Fatal("tree chicken never got halfway along a path")
+	}
+	data, err := json.Marshal(NewSaveState(g))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loaded := newTestGame(t, "map.tmx")
+	s := &SaveState{}
+	if err := json.Unmarshal(data, s); err != nil {
+		t.Fatal(err)
+	}
+	if s.Chickens[i].Tree == nil {
+		t.Fatal("tree wasn't saved")
+	}
+	if err := s.Restore(loaded); err != nil {
+		t.Fatal(err)
+	}
+
+	// the chicken goes on along the saved path rather than planning a new one
+	for tick := 0; tick < utils.UnitSize; tick++ {
+		g.tick()
+		loaded.tick()
+		a, b := c.Path, loaded.Chickens[i].Path
+		if (a == nil) != (b == nil) || a != nil && (a.CurrentCell != b.CurrentCell || len(a.Cells) != len(b.Cells)) {
+			t.Fatalf("tick %d after loading: path %v, want %v", tick, b, a)
+		}
+		if Checksum(loaded) != Checksum(g) {
+			t.Fatalf("loaded game went on differently %d ticks after loading", tick)
+		}
+	}
+	runTestGame(t, g, "", 300)
+	runTestGame(t, loaded, "", 300)
+	if Checksum(loaded) != Checksum(g) {
+		t.Fatal("loaded game went on differently from the saved one")
+	}
+}
+
+func TestRestoreStartsChangedTreesOver(t *testing.T) {
+	g := newTestGame(t, "map.tmx")
+	runTestGame(t, g, "", 100)
+	s := NewSaveState(g)
+	for i := range s.Chickens {
+		if s.Chickens[i].Tree != nil {
+			// as if the tree had lost a branch since the game was saved
+			s.Chickens[i].Tree.Children = s.Chickens[i].Tree.Children[1:]
+		}
+	}
+	if err := s.Restore(newTestGame(t, "map.tmx")); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,59 @@
+package game
+
+import (
+	"a-star/src/astar"
+	"a-star/src/utils"
+	"fmt"
+)
+
+// SearchView shows a search from the player to a cell growing one expansion
+// at a time, at the speed of the simulation clock.
+type SearchView struct {
+	Search *astar.Search
+	Name   string // algorithm the search stands for
+}
+
+// startSearchView starts showing a search from the player to the cell at
+// pixel x, y with the algorithm and heuristic picked in the controls.
+// Algorithms that can't be stepped are shown as A*.
+func startSearchView(g *Game, x, y int) {
+	dest := g.GridMap.GetGridCell(floorDiv(x, utils.UnitSize), floorDiv(y, utils.UnitSize))
+	if dest == nil {
+		return
+	}
+	px, py := g.Player.GetCenterPoint()
+
+	algorithm := astar.Algorithms[g.Algorithm]
+	if algorithm.GWeight == 0 && algorithm.HWeight == 0 {
+		algorithm = astar.Algorithms[0]
+	}
+	g.SearchView = &SearchView{
+		Search: astar.NewSearchWithOptions(g.GridMap, astar.GetCell(px, py), dest,
+			algorithm.GWeight, algorithm.HWeight, g.searchOptions()),
+		Name: algorithm.Name,
+	}
+}
+
+// stepSearchView advances the search shown, called once per tick.
+func stepSearchView(g *Game) {
+	if g.SearchView == nil {
+		return
+	}
+	for i := 0; i < utils.SearchStepsPerTick; i++ {
+		if g.SearchView.Search.Step() {
+			return
+		}
+	}
+}
+
+func (v *SearchView) Status() string {
+	s := v.Search
+	status := fmt.Sprintf("%s: %d expanded", v.Name, s.Expanded)
+	if !s.Done {
+		return status
+	}
+	if s.Path == nil {
+		return status + ", no path"
+	}
+	return status + fmt.Sprintf(", cost %g", s.Path.Cost())
+}
@@ -0,0 +1,142 @@
+package game
+
+import (
+	"a-star/src/utils"
+	"math"
+)
+
+// steerChickens spreads chickens out so they don't overlap each other or the
+// player. Steering only shifts a chicken's bodies by a small offset from the
+// position its path puts it at, so path following and the cell a chicken is
+// on are not affected.
+func steerChickens(g *Game) {
+	for i := range g.Chickens {
+		steerChicken(g, i)
+	}
+}
+
+func steerChicken(g *Game, i int) {
+	c := g.Chickens[i]
+	cx, cy := bodyCenter(c.Collision)
+
+	// separation: every body within the separation radius pushes the chicken
+	// away, the closer the harder
+	fx, fy := 0.0, 0.0
+	nearest := math.Inf(1)
+	overlapping := false
+	for j := -1; j < len(g.Chickens); j += 1 {
+		if j == i {
+			continue
+		}
+		body := g.Player.Collision
+		if j >= 0 {
+			body = g.Chickens[j].Collision
+		}
+
+		ox, oy := bodyCenter(body)
+		dx, dy := cx-ox, cy-oy
+		distance := math.Hypot(dx, dy)
+		nearest = math.Min(nearest, distance)
+		if distance >= utils.ChickenSeparationRadius {
+			continue
+		}
+		if distance == 0 {
+			// right on top of each other, split them sideways
+			dx, distance = float64(i-j), math.Abs(float64(i-j))
+		}
+		weight := (utils.ChickenSeparationRadius - distance) / utils.ChickenSeparationRadius
+		fx += dx / distance * weight
+		fy += dy / distance * weight
+		if hasCollision(0, 0, c.Collision, body) {
+			overlapping = true
+		}
+	}
+
+	speed := utils.ChickenSteerSpeed
+	if overlapping {
+		// resolve overlapping collision bodies faster than merely close ones
+		speed *= 2
+	}
+	sx, sy := steerStep(fx, speed), steerStep(fy, speed)
+
+	// obstacle avoidance: back off towards the path when pushed into a wall,
+	// and drift back once nothing is close any more
+	if hasMapCollisions(g, 0, 0, c.Collision) ||
+		(sx == 0 && sy == 0 && nearest > utils.ChickenSeparationRadius+utils.ChickenSteerSpeed*4) {
+		sx, sy = -sign(c.SteerX)*speed, -sign(c.SteerY)*speed
+		sx, sy = clampStep(sx, -c.SteerX), clampStep(sy, -c.SteerY)
+	}
+
+	sx = clampStep(sx, steerLimit(c.SteerX, sx))
+	sy = clampStep(sy, steerLimit(c.SteerY, sy))
+	if sx != 0 && canSteer(g, sx, 0, c.Collision) {
+		c.steer(sx, 0)
+	}
+	if sy != 0 && canSteer(g, 0, sy, c.Collision) {
+		c.steer(0, sy)
+	}
+}
+
+// steer moves the chicken's bodies without moving it along its path.
+func (c *Chicken) steer(dx, dy int) {
+	c.SteerX += dx
+	c.SteerY += dy
+	c.Sprite.X += dx
+	c.Sprite.Y += dy
+	c.Collision.X += dx
+	c.Collision.Y += dy
+}
+
+// canSteer reports whether body can be moved by dx, dy without leaving the map
+// or running into a wall. A body already stuck in a wall may always move.
+func canSteer(g *Game, dx, dy int, body CollisionBody) bool {
+	if body.X+dx < 0 || body.Y+dy < 0 ||
+		body.X+dx+body.Width > g.GameMap.Width*g.GameMap.TileWidth ||
+		body.Y+dy+body.Height > g.GameMap.Height*g.GameMap.TileHeight {
+		return false
+	}
+	return hasMapCollisions(g, 0, 0, body) || !hasMapCollisions(g, dx, dy, body)
+}
+
+func steerStep(force float64, speed int) int {
+	if math.Abs(force) < 0.05 {
+		return 0
+	}
+	if force < 0 {
+		return -speed
+	}
+	return speed
+}
+
+// steerLimit returns how far an offset of steer may still move in the direction
+// of step before reaching utils.ChickenMaxSteer.
+func steerLimit(steer, step int) int {
+	if step < 0 {
+		return -utils.ChickenMaxSteer - steer
+	}
+	return utils.ChickenMaxSteer - steer
+}
+
+// clampStep shortens step so it goes no further than limit in its direction.
+func clampStep(step, limit int) int {
+	if step > 0 && step > limit {
+		return max(limit, 0)
+	}
+	if step < 0 && step < limit {
+		return min(limit, 0)
+	}
+	return step
+}
+
+func sign(v int) int {
+	if v < 0 {
+		return -1
+	} else if v > 0 {
+		return 1
+	}
+	return 0
+}
+
+func bodyCenter(body CollisionBody) (x, y float64) {
+	return float64(body.X) + float64(body.Width)/2, float64(body.Y) + float64(body.Height)/2
+}
@@ -0,0 +1,106 @@
+package game
+
+import (
+	"a-star/src/utils"
+	"math"
+	"testing"
+)
+
+// steeringGame is the test map with only two chickens, both on the cell at
+// x, y, and the player out of their way.
+func steeringGame(t *testing.T, x, y int) (g *Game, a, b *Chicken) {
+	t.Helper()
+	g = newTestGame(t, "map.tmx")
+	if len(g.Chickens) < 2 {
+		t.Fatal("fewer than 2 chickens on the map")
+	}
+	g.Chickens = g.Chickens[:2]
+	for _, c := range g.Chickens {
+		c.Restart(g, x*utils.UnitSize, y*utils.UnitSize)
+	}
+	px, py := bodyCenter(g.Player.Collision)
+	if math.Hypot(px-float64(x*utils.UnitSize), py-float64(y*utils.UnitSize)) < utils.ChickenSeparationRadius*2 {
+		t.Fatal("player is next to the chickens")
+	}
+	return g, g.Chickens[0], g.Chickens[1]
+}
+
+func bodyDistance(a, b CollisionBody) float64 {
+	ax, ay := bodyCenter(a)
+	bx, by := bodyCenter(b)
+	return math.Hypot(ax-bx, ay-by)
+}
+
+func TestSteeringSeparatesChickens(t *testing.T) {
+	g, a, b := steeringGame(t, 18, 11)
+	for i := 0; i < 30; i++ {
+		steerChickens(g)
+	}
+
+	if d := bodyDistance(a.Collision, b.Collision); d < utils.ChickenMaxSteer {
+		t.Fatalf("chickens %g apart after steering", d)
+	}
+	for _, c := range g.Chickens {
+		if off := max(c.SteerX, -c.SteerX, c.SteerY, -c.SteerY); off > utils.ChickenMaxSteer {
+			t.Errorf("chicken steered %d,%d off its path", c.SteerX, c.SteerY)
+		}
+		// steering leaves where the path has the chicken alone
+		if c.XLoc != 18*utils.UnitSize || c.YLoc != 11*utils.UnitSize || !c.IsOnCell() {
+			t.Errorf("steering moved the chicken along its path to %d,%d", c.XLoc, c.YLoc)
+		}
+	}
+}
+
+func TestSteeringAvoidsWalls(t *testing.T) {
+	// a dead end with walls on three sides
+	g, a, b := steeringGame(t, 1, 1)
+	for i := 0; i < 30; i++ {
+		steerChickens(g)
+		for _, c := range g.Chickens {
+			if hasMapCollisions(g, 0, 0, c.Collision) {
+				t.Fatalf("chicken steered into a wall at %d,%d", c.Collision.X, c.Collision.Y)
+			}
+		}
+	}
+	if bodyDistance(a.Collision, b.Collision) == 0 {
+		t.Fatal("chickens still on top of each other")
+	}
+}
+
+func TestSteeringDriftsBack(t *testing.T) {
+	g, a, b := steeringGame(t, 18, 11)
+	// the other chicken is far away, nothing pushes this one any more
+	b.Restart(g, 24*utils.UnitSize, 15*utils.UnitSize)
+	a.steer(utils.ChickenMaxSteer, -utils.ChickenMaxSteer)
+	for i := 0; i < utils.ChickenMaxSteer*2; i++ {
+		steerChickens(g)
+	}
+	if a.SteerX != 0 || a.SteerY != 0 || a.Collision.X != a.XLoc+8 || a.Collision.Y != a.YLoc+16 {
+		t.Fatalf("chicken still steered %d,%d off its path", a.SteerX, a.SteerY)
+	}
+}
+
+func TestClampStep(t *testing.T) {
+	tests := []struct {
+		step, limit, want int
+	}{
+		{2, 5, 2},
+		{2, 1, 1},
+		{2, -3, 0},
+		{-2, -5, -2},
+		{-2, -1, -1},
+		{-2, 3, 0},
+		{0, 4, 0},
+	}
+	for _, test := range tests {
+		if got := clampStep(test.step, test.limit); got != test.want {
+			t.Errorf("clampStep(%d, %d) = %d, want %d", test.step, test.limit, got, test.want)
+		}
+	}
+	if got := steerLimit(utils.ChickenMaxSteer-3, 1); got != 3 {
+		t.Errorf("steerLimit leaves %d, want 3", got)
+	}
+	if got := steerLimit(-utils.ChickenMaxSteer+3, -1); got != -3 {
+		t.Errorf("steerLimit leaves %d, want -3", got)
+	}
+}
@@ -0,0 +1,23 @@
+// Package input abstracts where the game reads the keyboard and mouse from,
+// so that it can be played by a script as well as by a person. It doesn't
+// depend on Ebiten, the window provides a Device reading its own input.
+package input
+
+// Input is the state of the keyboard and mouse for the current frame.
+// Advance is called at the start of every frame, before anything is read.
+type Input interface {
+	Advance()
+	IsKeyPressed(key Key) bool
+	IsKeyJustPressed(key Key) bool
+	IsMouseButtonPressed(button MouseButton) bool
+	IsMouseButtonJustPressed(button MouseButton) bool
+	CursorPosition() (x, y int)
+	Wheel() (x, y float64)
+}
+
+// Device is the keyboard and mouse of a window, which can also list the keys
+// held down so they can be recorded.
+type Device interface {
+	Input
+	PressedKeys() []Key
+}
@@ -0,0 +1,80 @@
+package input
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Key is a keyboard key, named the way Ebiten names its keys, e.g. "A",
+// "Digit1", "Escape" or "ArrowLeft". Alt, Control, Meta and Shift stand for
+// either the left or the right one.
+type Key string
+
+// keys used by the game
+const (
+	KeyA       Key = "A"
+	KeyC       Key = "C"
+	KeyD       Key = "D"
+	KeyE       Key = "E"
+	KeyF       Key = "F"
+	KeyG       Key = "G"
+	KeyN       Key = "N"
+	KeyP       Key = "P"
+	KeyS       Key = "S"
+	KeyV       Key = "V"
+	KeyW       Key = "W"
+	KeyX       Key = "X"
+	KeyControl Key = "Control"
+	KeyEscape  Key = "Escape"
+	KeyF5      Key = "F5"
+	KeyF9      Key = "F9"
+)
+
+// DigitKey returns the key of digit n on the main keyboard.
+func DigitKey(n int) Key {
+	return Key(fmt.Sprintf("Digit%d", n))
+}
+
+type MouseButton int
+
+const (
+	MouseButtonLeft MouseButton = iota
+	MouseButtonRight
+	MouseButtonMiddle
+)
+
+// keyNames are the names of every key Ebiten knows.
+var keyNames = []string{
+	"A", "B", "C", "D", "E", "F", "G", "H", "I", "J", "K", "L", "M", "N", "O", "P", "Q",
+	"R", "S", "T", "U", "V", "W", "X", "Y", "Z", "Alt", "AltLeft", "AltRight",
+	"ArrowDown", "ArrowLeft", "ArrowRight", "ArrowUp", "Backquote", "Backslash",
+	"Backspace", "BracketLeft", "BracketRight", "CapsLock", "Comma", "ContextMenu",
+	"Control", "ControlLeft", "ControlRight", "Delete", "Digit0", "Digit1", "Digit2",
+	"Digit3", "Digit4", "Digit5", "Digit6", "Digit7", "Digit8", "Digit9", "End",
+	"Enter", "Equal", "Escape", "F1", "F2", "F3", "F4", "F5", "F6", "F7", "F8", "F9",
+	"F10", "F11", "F12", "Home", "Insert", "Meta", "MetaLeft", "MetaRight", "Minus",
+	"NumLock", "Numpad0", "Numpad1", "Numpad2", "Numpad3", "Numpad4", "Numpad5",
+	"Numpad6", "Numpad7", "Numpad8", "Numpad9", "NumpadAdd", "NumpadDecimal",
+	"NumpadDivide", "NumpadEnter", "NumpadEqual", "NumpadMultiply", "NumpadSubtract",
+	"PageDown", "PageUp", "Pause", "Period", "PrintScreen", "Quote", "ScrollLock",
+	"Semicolon", "Shift", "ShiftLeft", "ShiftRight", "Slash", "Space", "Tab",
+}
+
+// ParseKey returns the key called name, ignoring case.
+func ParseKey(name string) (Key, error) {
+	for _, keyName := range keyNames {
+		if strings.EqualFold(name, keyName) {
+			return Key(keyName), nil
+		}
+	}
+	return "", fmt.Errorf("unknown key %q", name)
+}
+
+// sides returns the left and right keys k stands for if it is a modifier.
+func (k Key) sides() []Key {
+	switch k {
+	case "Alt", "Control", "Meta", "Shift":
+		return []Key{k + "Left", k + "Right"}
+	}
+	return nil
+}
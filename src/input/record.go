@@ -0,0 +1,63 @@
+package input
+
+// recordedButtons are the mouse buttons a Recorder keeps track of.
+var recordedButtons = []MouseButton{MouseButtonLeft, MouseButtonRight, MouseButtonMiddle}
+
+// Recorder turns the input of a device into script events as they happen. The game reads them back through the script, so a
+// replay of the events gets exactly the same input as the game did.
+type Recorder struct {
+	*Script
+	device  Device
+	keys    map[Key]bool // held at the end of the last frame
+	buttons map[MouseButton]bool
+	x, y    int
+}
+
+func NewRecorder(device Device) *Recorder {
+	return &Recorder{
+		Script:  NewScript(nil),
+		device:  device,
+		keys:    map[Key]bool{},
+		buttons: map[MouseButton]bool{},
+	}
+}
+
+// Advance records what changed since the last frame and plays it.
+func (r *Recorder) Advance() {
+	frame := r.Frame() + 1
+	events := []Event{}
+
+	pressed := map[Key]bool{}
+	for _, key := range r.device.PressedKeys() {
+		pressed[key] = true
+		if !r.keys[key] {
+			events = append(events, Event{Frame: frame, Type: KeyDown, Key: key})
+		}
+	}
+	for key := range r.keys {
+		if !pressed[key] {
+			events = append(events, Event{Frame: frame, Type: KeyUp, Key: key})
+		}
+	}
+	r.keys = pressed
+
+	if x, y := r.device.CursorPosition(); x != r.x || y != r.y {
+		events = append(events, Event{Frame: frame, Type: MoveCursor, X: x, Y: y})
+		r.x, r.y = x, y
+	}
+	for _, button := range recordedButtons {
+		down := r.device.IsMouseButtonPressed(button)
+		if down && !r.buttons[button] {
+			events = append(events, Event{Frame: frame, Type: ButtonDown, Button: button})
+		} else if !down && r.buttons[button] {
+			events = append(events, Event{Frame: frame, Type: ButtonUp, Button: button})
+		}
+		r.buttons[button] = down
+	}
+	if _, wheel := r.device.Wheel(); wheel != 0 {
+		events = append(events, Event{Frame: frame, Type: Scroll, Wheel: wheel})
+	}
+
+	r.Add(events...)
+	r.Script.Advance()
+}
@@ -0,0 +1,232 @@
+package input
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type EventType int
+
+const (
+	KeyDown EventType = iota
+	KeyUp
+	ButtonDown
+	ButtonUp
+	MoveCursor
+	Scroll
+)
+
+// Event changes the input at the start of a frame.
+type Event struct {
+	Frame  int         `json:"frame"`
+	Type   EventType   `json:"type"`
+	Key    Key         `json:"key,omitempty"`    // KeyDown and KeyUp
+	Button MouseButton `json:"button,omitempty"` // ButtonDown and ButtonUp
+	X      int         `json:"x,omitempty"`      // MoveCursor
+	Y      int         `json:"y,omitempty"`
+	Wheel  float64     `json:"wheel,omitempty"` // Scroll
+}
+
+// Script is input played back from a list of events. Advance has to be
+// called before each frame, the first frame being frame 1.
+type Script struct {
+	events  []Event
+	next    int
+	frame   int
+	keys    map[Key]int // frame each held key was pressed on
+	buttons map[MouseButton]int
+	x, y    int
+	wheel   float64
+}
+
+func NewScript(events []Event) *Script {
+	events = append([]Event{}, events...)
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Frame < events[j].Frame })
+	return &Script{
+		events:  events,
+		keys:    map[Key]int{},
+		buttons: map[MouseButton]int{},
+	}
+}
+
+// Add adds events to the end of the script. They can't happen before the
+// current frame.
+func (s *Script) Add(events ...Event) {
+	s.events = append(s.events, events...)
+}
+
+// Events returns every event of the script, played or not.
+func (s *Script) Events() []Event {
+	return s.events
+}
+
+// Advance moves on to the next frame and applies its events.
+func (s *Script) Advance() {
+	s.frame += 1
+	s.wheel = 0
+	for ; s.next < len(s.events) && s.events[s.next].Frame <= s.frame; s.next++ {
+		e := s.events[s.next]
+		switch e.Type {
+		case KeyDown:
+			if _, ok := s.keys[e.Key]; !ok {
+				s.keys[e.Key] = s.frame
+			}
+		case KeyUp:
+			delete(s.keys, e.Key)
+		case ButtonDown:
+			if _, ok := s.buttons[e.Button]; !ok {
+				s.buttons[e.Button] = s.frame
+			}
+		case ButtonUp:
+			delete(s.buttons, e.Button)
+		case MoveCursor:
+			s.x, s.y = e.X, e.Y
+		case Scroll:
+			s.wheel += e.Wheel
+		}
+	}
+}
+
+// Frame returns the number of the current frame.
+func (s *Script) Frame() int {
+	return s.frame
+}
+
+// Done reports whether every event has been played.
+func (s *Script) Done() bool {
+	return s.next >= len(s.events)
+}
+
+func (s *Script) IsKeyPressed(key Key) bool {
+	_, ok := s.keyFrame(key)
+	return ok
+}
+
+func (s *Script) IsKeyJustPressed(key Key) bool {
+	frame, ok := s.keyFrame(key)
+	return ok && frame == s.frame
+}
+
+// keyFrame returns the frame key was pressed on. A modifier like Control is
+// held while either of its sides is, from when the first was pressed.
+func (s *Script) keyFrame(key Key) (int, bool) {
+	frame, ok := s.keys[key]
+	for _, side := range key.sides() {
+		if sideFrame, sideOk := s.keys[side]; sideOk && (!ok || sideFrame < frame) {
+			frame, ok = sideFrame, true
+		}
+	}
+	return frame, ok
+}
+
+func (s *Script) IsMouseButtonPressed(button MouseButton) bool {
+	_, ok := s.buttons[button]
+	return ok
+}
+
+func (s *Script) IsMouseButtonJustPressed(button MouseButton) bool {
+	frame, ok := s.buttons[button]
+	return ok && frame == s.frame
+}
+
+func (s *Script) CursorPosition() (x, y int) {
+	return s.x, s.y
+}
+
+func (s *Script) Wheel() (x, y float64) {
+	return 0, s.wheel
+}
+
+// ParseScript reads a script with one event per line, each starting with the
+// frame it happens on:
+//
+//	# walk right for a second, then click the play button
+//	1 press D
+//	61 release D
+//	61 click 90 30
+//
+// The commands are press and release with a key name, see Key,
+// move, click and rightclick with screen coordinates, and scroll with the
+// number of wheel steps. A click releases the button on the next frame.
+func ParseScript(r io.Reader) (*Script, error) {
+	events := []Event{}
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		lineEvents, err := parseEvents(fields)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		events = append(events, lineEvents...)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return NewScript(events), nil
+}
+
+func parseEvents(fields []string) ([]Event, error) {
+	if len(fields) < 2 {
+		return nil, fmt.Errorf("expected a frame and a command")
+	}
+	frame, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return nil, fmt.Errorf("bad frame %q", fields[0])
+	}
+	command, args := fields[1], fields[2:]
+
+	switch command {
+	case "press", "release":
+		if len(args) != 1 {
+			return nil, fmt.Errorf("%s takes a key", command)
+		}
+		key, err := ParseKey(args[0])
+		if err != nil {
+			return nil, err
+		}
+		eventType := KeyDown
+		if command == "release" {
+			eventType = KeyUp
+		}
+		return []Event{{Frame: frame, Type: eventType, Key: key}}, nil
+
+	case "move", "click", "rightclick":
+		if len(args) != 2 {
+			return nil, fmt.Errorf("%s takes x and y", command)
+		}
+		x, errX := strconv.Atoi(args[0])
+		y, errY := strconv.Atoi(args[1])
+		if errX != nil || errY != nil {
+			return nil, fmt.Errorf("bad position %s %s", args[0], args[1])
+		}
+		events := []Event{{Frame: frame, Type: MoveCursor, X: x, Y: y}}
+		if command == "move" {
+			return events, nil
+		}
+		button := MouseButtonLeft
+		if command == "rightclick" {
+			button = MouseButtonRight
+		}
+		return append(events,
+			Event{Frame: frame, Type: ButtonDown, Button: button},
+			Event{Frame: frame + 1, Type: ButtonUp, Button: button}), nil
+
+	case "scroll":
+		if len(args) != 1 {
+			return nil, fmt.Errorf("scroll takes a number of steps")
+		}
+		wheel, err := strconv.ParseFloat(args[0], 64)
+		if err != nil {
+			return nil, fmt.Errorf("bad scroll %q", args[0])
+		}
+		return []Event{{Frame: frame, Type: Scroll, Wheel: wheel}}, nil
+	}
+	return nil, fmt.Errorf("unknown command %q", command)
+}
@@ -0,0 +1,171 @@
+// Package ui is a small set of widgets drawn on top of the game: buttons,
+// toggles, dropdowns and labels. Widgets are laid out once, e.g. with Column,
+// and then updated and drawn every frame by a UI. Widgets draw on a Canvas,
+// which the game window provides.
+package ui
+
+import (
+	"a-star/src/input"
+	"image"
+	"image/color"
+)
+
+// Canvas is what widgets are drawn on. Widgets lay out text for a fixed width
+// font of charWidth by lineHeight pixels, like Ebiten's debug font.
+type Canvas interface {
+	FillRect(rect image.Rectangle, c color.Color)
+	DrawText(text string, x, y int)
+	// DrawImage draws img with its top left corner at x, y, its colors
+	// scaled by brightness
+	DrawImage(img image.Image, x, y int, brightness float64)
+}
+
+// Widget is anything a UI can lay out, update and draw.
+type Widget interface {
+	Size() image.Point // size the widget would like to have
+	Rect() image.Rectangle
+	SetRect(rect image.Rectangle)
+	Update(cursor Cursor) // called every frame with the state of the mouse
+	Draw(screen Canvas)
+}
+
+// popup is a widget that can open over the widgets after it, like an open
+// dropdown. While open it gets the mouse to itself.
+type popup interface {
+	Widget
+	IsOpen() bool
+	DrawPopup(screen Canvas)
+}
+
+// Cursor is the state of the mouse for a frame, along with the input it was
+// read from for keyboard shortcuts.
+type Cursor struct {
+	X, Y    int
+	Down    bool // left button held
+	Clicked bool // left button pressed this frame
+	input   input.Input
+}
+
+func (c Cursor) In(rect image.Rectangle) bool {
+	return image.Pt(c.X, c.Y).In(rect)
+}
+
+type UI struct {
+	Widgets  []Widget
+	hasMouse bool
+}
+
+func New(widgets ...Widget) *UI {
+	return &UI{Widgets: widgets}
+}
+
+// Update updates every widget with the current state of in. Clicks that land
+// on a widget shouldn't also be handled by the game, see HasMouse.
+func (u *UI) Update(in input.Input) {
+	x, y := in.CursorPosition()
+	cursor := Cursor{
+		X:       x,
+		Y:       y,
+		Down:    in.IsMouseButtonPressed(input.MouseButtonLeft),
+		Clicked: in.IsMouseButtonJustPressed(input.MouseButtonLeft),
+		input:   in,
+	}
+
+	if open := u.openPopup(); open != nil {
+		// clicking anywhere else just closes it
+		u.hasMouse = true
+		open.Update(cursor)
+		return
+	}
+
+	u.hasMouse = false
+	for _, w := range u.Widgets {
+		if cursor.In(w.Rect()) {
+			u.hasMouse = true
+		}
+		w.Update(cursor)
+	}
+}
+
+// HasMouse reports whether the mouse was over a widget on the last Update.
+func (u *UI) HasMouse() bool {
+	return u.hasMouse
+}
+
+func (u *UI) Draw(screen Canvas) {
+	for _, w := range u.Widgets {
+		w.Draw(screen)
+	}
+	if open := u.openPopup(); open != nil {
+		open.DrawPopup(screen)
+	}
+}
+
+func (u *UI) openPopup() popup {
+	for _, w := range u.Widgets {
+		if p, ok := w.(popup); ok && p.IsOpen() {
+			return p
+		}
+	}
+	return nil
+}
+
+// Column places widgets below each other starting at x, y, with spacing
+// pixels between them, and returns them.
+func Column(x, y, spacing int, widgets ...Widget) []Widget {
+	for _, w := range widgets {
+		size := w.Size()
+		w.SetRect(image.Rect(x, y, x+size.X, y+size.Y))
+		y += size.Y + spacing
+	}
+	return widgets
+}
+
+// base keeps the rect of a widget and whether it is hovered or pressed.
+type base struct {
+	rect    image.Rectangle
+	hovered bool
+	pressed bool
+}
+
+func (b *base) Rect() image.Rectangle {
+	return b.rect
+}
+
+func (b *base) SetRect(rect image.Rectangle) {
+	b.rect = rect
+}
+
+// track updates the hover and pressed state and reports whether the widget
+// was clicked or one of keys was pressed.
+func (b *base) track(cursor Cursor, keys []input.Key) bool {
+	b.hovered = cursor.In(b.rect)
+	b.pressed = b.hovered && cursor.Down
+	return b.hovered && cursor.Clicked || cursor.anyKeyPressed(keys)
+}
+
+func (c Cursor) anyKeyPressed(keys []input.Key) bool {
+	for _, key := range keys {
+		if c.input != nil && c.input.IsKeyJustPressed(key) {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	backgroundColor = color.RGBA{0x30, 0x30, 0x30, 0xc0}
+	hoverColor      = color.RGBA{0x50, 0x50, 0x50, 0xd0}
+	pressedColor    = color.RGBA{0x20, 0x20, 0x20, 0xe0}
+)
+
+// drawBackground fills rect in the color for the widget's state.
+func (b *base) drawBackground(screen Canvas, rect image.Rectangle) {
+	c := backgroundColor
+	if b.pressed {
+		c = pressedColor
+	} else if b.hovered {
+		c = hoverColor
+	}
+	screen.FillRect(rect, c)
+}
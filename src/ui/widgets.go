@@ -0,0 +1,212 @@
+package ui
+
+import (
+	"a-star/src/input"
+	"image"
+)
+
+// size of a character of the font text is drawn with
+const (
+	charWidth  = 6
+	lineHeight = 16
+	padding    = 4
+)
+
+func textSize(text string) image.Point {
+	return image.Pt(len(text)*charWidth+2*padding, lineHeight+2*padding)
+}
+
+func drawText(screen Canvas, text string, rect image.Rectangle) {
+	screen.DrawText(text, rect.Min.X+padding, rect.Min.Y+padding)
+}
+
+// Label shows a line of text. With TextFunc set the text is asked for every
+// frame instead.
+type Label struct {
+	base
+	Text     string
+	TextFunc func() string
+}
+
+func (l *Label) text() string {
+	if l.TextFunc != nil {
+		return l.TextFunc()
+	}
+	return l.Text
+}
+
+func (l *Label) Size() image.Point {
+	return textSize(l.text())
+}
+
+func (l *Label) Update(cursor Cursor) {}
+
+func (l *Label) Draw(screen Canvas) {
+	drawText(screen, l.text(), l.rect)
+}
+
+// Button calls OnClick when clicked or when one of Keys is pressed. It shows
+// Image if it has one, Text otherwise.
+type Button struct {
+	base
+	Text    string
+	Image   image.Image
+	Keys    []input.Key
+	OnClick func()
+}
+
+func (b *Button) Size() image.Point {
+	if b.Image != nil {
+		return b.Image.Bounds().Size()
+	}
+	return textSize(b.Text)
+}
+
+func (b *Button) Update(cursor Cursor) {
+	if b.track(cursor, b.Keys) && b.OnClick != nil {
+		b.OnClick()
+	}
+}
+
+func (b *Button) Draw(screen Canvas) {
+	if b.Image == nil {
+		b.drawBackground(screen, b.rect)
+		drawText(screen, b.Text, b.rect)
+		return
+	}
+
+	brightness := 1.0
+	if b.pressed {
+		brightness = 0.7
+	} else if b.hovered {
+		brightness = 1.15
+	}
+	screen.DrawImage(b.Image, b.rect.Min.X, b.rect.Min.Y, brightness)
+}
+
+// Toggle flips Value when clicked or when one of Keys is pressed.
+type Toggle struct {
+	base
+	Text     string
+	Value    *bool
+	Keys     []input.Key
+	OnChange func(on bool)
+}
+
+func (t *Toggle) label() string {
+	if *t.Value {
+		return "[x] " + t.Text
+	}
+	return "[ ] " + t.Text
+}
+
+func (t *Toggle) Size() image.Point {
+	return textSize(t.label())
+}
+
+func (t *Toggle) Update(cursor Cursor) {
+	if !t.track(cursor, t.Keys) {
+		return
+	}
+	*t.Value = !*t.Value
+	if t.OnChange != nil {
+		t.OnChange(*t.Value)
+	}
+}
+
+func (t *Toggle) Draw(screen Canvas) {
+	t.drawBackground(screen, t.rect)
+	drawText(screen, t.label(), t.rect)
+}
+
+// Dropdown picks one of Options into Selected. Clicking it opens the list of
+// options, while each of Keys selects the next option straight away.
+type Dropdown struct {
+	base
+	Text     string
+	Options  []string
+	Selected *int
+	Keys     []input.Key
+	OnChange func(selected int)
+	open     bool
+	hover    int // option under the mouse while open, -1 for none
+}
+
+func (d *Dropdown) label(option string) string {
+	return d.Text + ": " + option + " v"
+}
+
+func (d *Dropdown) Size() image.Point {
+	size := image.Point{}
+	for _, option := range d.Options {
+		size.X = max(size.X, textSize(d.label(option)).X)
+	}
+	size.Y = textSize("").Y
+	return size
+}
+
+func (d *Dropdown) IsOpen() bool {
+	return d.open
+}
+
+// optionRect returns where option i is listed while the dropdown is open.
+func (d *Dropdown) optionRect(i int) image.Rectangle {
+	height := d.rect.Dy()
+	return image.Rect(d.rect.Min.X, d.rect.Max.Y+i*height, d.rect.Max.X, d.rect.Max.Y+(i+1)*height)
+}
+
+func (d *Dropdown) Update(cursor Cursor) {
+	if !d.open {
+		if d.track(cursor, nil) {
+			d.open = true
+			d.hover = -1
+		} else if cursor.anyKeyPressed(d.Keys) && len(d.Options) > 0 {
+			d.selectOption((*d.Selected + 1) % len(d.Options))
+		}
+		return
+	}
+
+	d.hover = -1
+	for i := range d.Options {
+		if cursor.In(d.optionRect(i)) {
+			d.hover = i
+		}
+	}
+	if cursor.Clicked {
+		if d.hover >= 0 {
+			d.selectOption(d.hover)
+		}
+		d.open = false
+	}
+}
+
+func (d *Dropdown) selectOption(i int) {
+	if *d.Selected == i {
+		return
+	}
+	*d.Selected = i
+	if d.OnChange != nil {
+		d.OnChange(i)
+	}
+}
+
+func (d *Dropdown) Draw(screen Canvas) {
+	d.drawBackground(screen, d.rect)
+	option := ""
+	if *d.Selected < len(d.Options) {
+		option = d.Options[*d.Selected]
+	}
+	drawText(screen, d.label(option), d.rect)
+}
+
+func (d *Dropdown) DrawPopup(screen Canvas) {
+	for i, option := range d.Options {
+		rect := d.optionRect(i)
+		c := backgroundColor
+		if i == d.hover {
+			c = hoverColor
+		}
+		screen.FillRect(rect, c)
+		drawText(screen, option, rect)
+	}
+}
@@ -24,6 +24,8 @@ const (
 
 	ChickenIdleState = 0
 	ChickenWalkState = 2
+
+	SearchNodeLimit = 1024
 )
 
 // Directions
@@ -41,7 +43,87 @@ const (
 	ChickenNumOfDirections
 )
 
+// Patrol modes
+const (
+	PatrolLoop = iota
+	PatrolPingPong
+	PatrolRandom
+)
+
+const PatrolRoutesGroup = "PatrolRoutes"
+
+// Screens
+const (
+	PlayScreen = iota
+	LevelSelectScreen
+)
+
+// file in the assets listing the levels, and the object group of their exits
+const (
+	LevelsFile = "levels.json"
+	ExitsGroup = "Exits"
+)
+
+// where the game is saved and loaded from with F5 and F9
+const SaveGamePath = "savegame.json"
+
+// where X exports the search to the cursor, with .json and .svg added
+const ExportPath = "search"
+
+// Screen capture settings
+const (
+	CapturePath      = "capture.gif" // where G saves the screen capture
+	CaptureEvery     = 2             // frames between the frames kept
+	CaptureMaxFrames = 600
+	CaptureCellSize  = 16 // size of a cell in captures drawn without a window, in pixels
+)
+
+// directory of the behaviour tree files referenced by chicken spawn points,
+// within the assets
+const BehaviourTreeDir = "behaviours"
+
+// Chicken behaviours
+const (
+	BehaviourRest = iota
+	BehaviourWander
+	BehaviourChase
+	BehaviourFlee
+)
+
+// Default behaviour settings, distances are in cells and times in frames
+const (
+	ChickenSightRadius  = 5
+	ChickenWanderRadius = 6
+	ChickenFleeRadius   = 8
+	ChickenRestTime     = 120
+	ChickenRepathDelay  = 30
+)
+
+// Window and camera settings
+const (
+	MaxWindowWidth  = 960
+	MaxWindowHeight = 640
+	CameraMinZoom   = 0.5
+	CameraMaxZoom   = 3
+	CameraZoomStep  = 0.1 // zoom change per mouse wheel step
+)
+
+// Simulation clock settings
+const (
+	TicksPerSecond     = 60 // ticks at normal speed
+	SearchStepsPerTick = 1  // nodes the search view expands every tick
+)
+
+// Steering settings, in pixels
+const (
+	ChickenSeparationRadius = 20
+	ChickenSteerSpeed       = 1
+	ChickenMaxSteer         = 10 // furthest a chicken is pushed off its path
+)
+
 var (
-	TilesetSheets   = []string{"grass_hill.png", "fences.png"}
 	CollisionLayers = []int{CollisionLayer}
+
+	// how many times faster than normal the simulation can run
+	SimulationSpeeds = []int{1, 2, 4, 8}
 )
@@ -0,0 +1,79 @@
+package view
+
+import (
+	"image"
+	"io/fs"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+// AssetCache loads each image of the assets once and hands out the same
+// *ebiten.Image afterwards. Sprite frames cut from a sheet are kept too, so
+// drawing doesn't slice the sheet again every frame.
+type AssetCache struct {
+	assets    fs.FS
+	images    map[string]*ebiten.Image
+	frames    map[spriteFrame]*ebiten.Image
+	converted map[image.Image]*ebiten.Image
+}
+
+type spriteFrame struct {
+	sheet *ebiten.Image
+	rect  image.Rectangle
+}
+
+func NewAssetCache(assets fs.FS) *AssetCache {
+	return &AssetCache{
+		assets:    assets,
+		images:    map[string]*ebiten.Image{},
+		frames:    map[spriteFrame]*ebiten.Image{},
+		converted: map[image.Image]*ebiten.Image{},
+	}
+}
+
+// Image returns the image at path in the assets, or nil if it can't be loaded.
+func (a *AssetCache) Image(path string) *ebiten.Image {
+	if img, ok := a.images[path]; ok {
+		return img
+	}
+	img := loadImage(a.assets, path)
+	// failures are cached as well so a missing file isn't opened every frame
+	a.images[path] = img
+	return img
+}
+
+// Frame returns the part of sheet within rect.
+func (a *AssetCache) Frame(sheet *ebiten.Image, rect image.Rectangle) *ebiten.Image {
+	key := spriteFrame{sheet, rect}
+	if frame, ok := a.frames[key]; ok {
+		return frame
+	}
+	frame := sheet.SubImage(rect).(*ebiten.Image)
+	a.frames[key] = frame
+	return frame
+}
+
+// FromImage returns img as an *ebiten.Image, e.g. the image of a button,
+// converting each image only once.
+func (a *AssetCache) FromImage(img image.Image) *ebiten.Image {
+	if converted, ok := a.converted[img]; ok {
+		return converted
+	}
+	converted := ebiten.NewImageFromImage(img)
+	a.converted[img] = converted
+	return converted
+}
+
+func loadImage(assets fs.FS, filepath string) *ebiten.Image {
+	file, err := assets.Open(filepath)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+	image, _, err := ebitenutil.NewImageFromReader(file)
+	if err != nil {
+		return nil
+	}
+	return image
+}
@@ -0,0 +1,32 @@
+package view
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// canvas lets the widgets of the controls draw on the screen.
+type canvas struct {
+	screen *ebiten.Image
+	images *AssetCache
+}
+
+func (c *canvas) FillRect(rect image.Rectangle, col color.Color) {
+	vector.DrawFilledRect(c.screen, float32(rect.Min.X), float32(rect.Min.Y),
+		float32(rect.Dx()), float32(rect.Dy()), col, false)
+}
+
+func (c *canvas) DrawText(text string, x, y int) {
+	ebitenutil.DebugPrintAt(c.screen, text, x, y)
+}
+
+func (c *canvas) DrawImage(img image.Image, x, y int, brightness float64) {
+	drawOptions := ebiten.DrawImageOptions{}
+	drawOptions.GeoM.Translate(float64(x), float64(y))
+	drawOptions.ColorScale.Scale(float32(brightness), float32(brightness), float32(brightness), 1)
+	c.screen.DrawImage(c.images.FromImage(img), &drawOptions)
+}
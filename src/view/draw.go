@@ -0,0 +1,135 @@
+package view
+
+import (
+	"a-star/src/astar"
+	"a-star/src/game"
+	"a-star/src/utils"
+	"fmt"
+	"image"
+	"image/color"
+	"strconv"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+	"github.com/lafriks/go-tiled"
+)
+
+var (
+	closedColor = color.RGBA{0x20, 0x40, 0xa0, 0x60}
+	openColor   = color.RGBA{0x20, 0xa0, 0x40, 0x60}
+	pathColor   = color.RGBA{0xe0, 0xc0, 0x20, 0x90}
+)
+
+func drawLayer(gMap *tiled.Map, layer *tiled.Layer, tilesets map[*tiled.Tileset]*Tileset, ms int, view image.Rectangle, screen *ebiten.Image, drawOptions ebiten.DrawImageOptions) {
+	// only the tiles in view are drawn
+	for tileY := view.Min.Y; tileY < view.Max.Y; tileY += 1 {
+		for tileX := view.Min.X; tileX < view.Max.X; tileX += 1 {
+			// find img of tile to draw
+			tileToDraw := layer.Tiles[tileY*gMap.Width+tileX]
+			if tileToDraw.IsNil() {
+				continue
+			}
+
+			// draw tile
+			tilesets[tileToDraw.Tileset].DrawTile(screen, tileToDraw.ID, ms,
+				gMap.TileWidth*tileX, gMap.TileHeight*tileY, gMap.TileHeight, drawOptions)
+		}
+	}
+}
+
+func drawPlayer(images *AssetCache, player *game.Player, screen *ebiten.Image, drawOptions ebiten.DrawImageOptions) {
+	sheet := images.Image("player.png")
+	if sheet == nil {
+		return
+	}
+	screen.DrawImage(images.Frame(sheet, image.Rect(player.Frame*utils.PlayerSpriteWidth,
+		(player.State*utils.NumOfDirections+player.Direction)*utils.PlayerSpriteHeight,
+		player.Frame*utils.PlayerSpriteWidth+utils.PlayerSpriteWidth,
+		(player.State*utils.NumOfDirections+player.Direction)*utils.PlayerSpriteHeight+utils.PlayerSpriteHeight)),
+		translated(drawOptions, float64(player.XLoc), float64(player.YLoc)))
+}
+
+func drawChickens(images *AssetCache, chickens []*game.Chicken, screen *ebiten.Image, drawOptions ebiten.DrawImageOptions) {
+	sheet := images.Image("chicken.png")
+	if sheet == nil {
+		return
+	}
+	for _, c := range chickens {
+		screen.DrawImage(images.Frame(sheet, image.Rect(c.Frame*c.Sprite.Width,
+			(c.State*utils.ChickenNumOfDirections+c.Direction)*c.Sprite.Height,
+			c.Frame*c.Sprite.Width+c.Sprite.Width,
+			(c.State*utils.ChickenNumOfDirections+c.Direction)*c.Sprite.Height+c.Sprite.Height)),
+			translated(drawOptions, float64(c.XLoc+c.SteerX), float64(c.YLoc+c.SteerY)))
+	}
+}
+
+func (v *View) drawFences(screen *ebiten.Image, drawOptions ebiten.DrawImageOptions) {
+	g := v.Game
+	if g.FenceTile == nil {
+		return
+	}
+	tileset := v.tilesets[g.FenceTile.Tileset]
+	for cell := range g.Fences {
+		tileset.DrawTile(screen, g.FenceTile.ID, 0, cell.X*g.GameMap.TileWidth, cell.Y*g.GameMap.TileHeight,
+			g.GameMap.TileHeight, drawOptions)
+	}
+}
+
+func drawSearchView(g *game.Game, screen *ebiten.Image) {
+	if g.SearchView == nil {
+		return
+	}
+	s := g.SearchView.Search
+	for _, cell := range s.ClosedCells() {
+		fillCell(g, screen, cell, closedColor)
+	}
+	for _, cell := range s.OpenCells() {
+		fillCell(g, screen, cell, openColor)
+	}
+	if s.Path != nil {
+		for _, cell := range s.Path.Cells {
+			fillCell(g, screen, cell, pathColor)
+		}
+	}
+}
+
+func fillCell(g *game.Game, screen *ebiten.Image, cell *astar.Cell, c color.Color) {
+	x, y := g.Camera.WorldToScreen(float64(cell.X*g.GameMap.TileWidth), float64(cell.Y*g.GameMap.TileHeight))
+	vector.DrawFilledRect(screen, float32(x), float32(y),
+		float32(float64(g.GameMap.TileWidth)*g.Camera.Zoom), float32(float64(g.GameMap.TileHeight)*g.Camera.Zoom), c, false)
+}
+
+func drawEditor(g *game.Game, screen *ebiten.Image) {
+	if !g.Editor.Enabled {
+		return
+	}
+
+	// show the cost of every walkable cell in view that isn't the default
+	view := g.Camera.VisibleTiles(g.GameMap.TileWidth, g.GameMap.TileHeight, g.GameMap.Width, g.GameMap.Height)
+	for y := view.Min.Y; y < view.Max.Y; y++ {
+		for x := view.Min.X; x < view.Max.X; x++ {
+			cell := g.GridMap.GetGridCell(x, y)
+			if cell.IsWalkable && cell.Cost != 1 {
+				screenX, screenY := g.Camera.WorldToScreen(float64(x*g.GameMap.TileWidth+12), float64(y*g.GameMap.TileHeight+8))
+				ebitenutil.DebugPrintAt(screen, strconv.FormatFloat(cell.Cost, 'f', -1, 64), screenX, screenY)
+			}
+		}
+	}
+}
+
+func drawLevelSelect(g *game.Game, screen *ebiten.Image) {
+	screen.Fill(color.RGBA{0x20, 0x30, 0x20, 0xff})
+	ebitenutil.DebugPrintAt(screen, "Select a level", 40, 24)
+	for i, level := range g.Levels {
+		button := game.LevelButton(i)
+		label := level.Name
+		if i < 9 {
+			label = fmt.Sprintf("[%d] %s", i+1, level.Name)
+		}
+		if i == g.Level {
+			label += "  (current)"
+		}
+		ebitenutil.DebugPrintAt(screen, label, button.X, button.Y)
+	}
+}
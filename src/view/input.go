@@ -0,0 +1,69 @@
+package view
+
+import (
+	"a-star/src/input"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// Device reads the keyboard and mouse of the window.
+type Device struct{}
+
+// Advance does nothing, Ebiten has already read the input for the frame.
+func (Device) Advance() {}
+
+func (Device) IsKeyPressed(key input.Key) bool {
+	k, ok := ebitenKey(key)
+	return ok && ebiten.IsKeyPressed(k)
+}
+
+func (Device) IsKeyJustPressed(key input.Key) bool {
+	k, ok := ebitenKey(key)
+	return ok && inpututil.IsKeyJustPressed(k)
+}
+
+func (Device) IsMouseButtonPressed(button input.MouseButton) bool {
+	return ebiten.IsMouseButtonPressed(ebitenButtons[button])
+}
+
+func (Device) IsMouseButtonJustPressed(button input.MouseButton) bool {
+	return inpututil.IsMouseButtonJustPressed(ebitenButtons[button])
+}
+
+func (Device) CursorPosition() (x, y int) {
+	return ebiten.CursorPosition()
+}
+
+func (Device) Wheel() (x, y float64) {
+	return ebiten.Wheel()
+}
+
+func (Device) PressedKeys() []input.Key {
+	keys := []input.Key{}
+	for _, key := range inpututil.AppendPressedKeys(nil) {
+		keys = append(keys, input.Key(key.String()))
+	}
+	return keys
+}
+
+var ebitenButtons = map[input.MouseButton]ebiten.MouseButton{
+	input.MouseButtonLeft:   ebiten.MouseButtonLeft,
+	input.MouseButtonRight:  ebiten.MouseButtonRight,
+	input.MouseButtonMiddle: ebiten.MouseButtonMiddle,
+}
+
+// ebitenKeys caches the Ebiten key of each key name looked up.
+var ebitenKeys = map[input.Key]ebiten.Key{}
+
+func ebitenKey(key input.Key) (ebiten.Key, bool) {
+	if k, ok := ebitenKeys[key]; ok {
+		return k, true
+	}
+	var k ebiten.Key
+	if err := k.UnmarshalText([]byte(key)); err != nil {
+		return 0, false
+	}
+	ebitenKeys[key] = k
+	return k, true
+}
@@ -0,0 +1,138 @@
+package view
+
+import (
+	"image"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/lafriks/go-tiled"
+)
+
+// MapRenderer draws the tile layers of a map. Layers without animated tiles
+// never change on their own, so they are drawn once into offscreen chunks of
+// ChunkSize by ChunkSize tiles, which are then drawn whole every frame. Chunks
+// are only rendered once they come into view.
+type MapRenderer struct {
+	gameMap  *tiled.Map
+	tilesets map[*tiled.Tileset]*Tileset
+	static   []bool // layers drawn from chunks
+	chunks   map[chunkKey]*ebiten.Image
+	dirty    map[chunkKey]bool
+	marginX  int // how many tiles away an image can reach into a chunk from
+	marginY  int
+}
+
+type chunkKey struct {
+	layer int
+	chunk image.Point
+}
+
+const ChunkSize = 16
+
+func NewMapRenderer(gameMap *tiled.Map, tilesets map[*tiled.Tileset]*Tileset) *MapRenderer {
+	r := &MapRenderer{
+		gameMap:  gameMap,
+		tilesets: tilesets,
+		chunks:   map[chunkKey]*ebiten.Image{},
+		dirty:    map[chunkKey]bool{},
+	}
+	for _, layer := range gameMap.Layers {
+		static := true
+		for _, tile := range layer.Tiles {
+			if r.isAnimated(tile) {
+				static = false
+				break
+			}
+		}
+		r.static = append(r.static, static)
+	}
+
+	// tiles bigger than the map grid stick out of their cell
+	for _, ts := range gameMap.Tilesets {
+		width, height := ts.TileWidth, ts.TileHeight
+		if ts.TileOffset != nil {
+			width += abs(ts.TileOffset.X)
+			height += abs(ts.TileOffset.Y)
+		}
+		r.marginX = max(r.marginX, (width+gameMap.TileWidth-1)/gameMap.TileWidth)
+		r.marginY = max(r.marginY, (height+gameMap.TileHeight-1)/gameMap.TileHeight)
+	}
+	return r
+}
+
+func (r *MapRenderer) isAnimated(tile *tiled.LayerTile) bool {
+	if tile.IsNil() {
+		return false
+	}
+	tileset := r.tilesets[tile.Tileset]
+	return tileset != nil && len(tileset.animations[tile.ID]) > 0
+}
+
+// Draw draws the tiles within view, a range of tiles, at ms milliseconds into
+// the game.
+func (r *MapRenderer) Draw(screen *ebiten.Image, ms int, view image.Rectangle, drawOptions ebiten.DrawImageOptions) {
+	gMap := r.gameMap
+	for i, layer := range gMap.Layers {
+		if !r.static[i] {
+			drawLayer(gMap, layer, r.tilesets, ms, view, screen, drawOptions)
+			continue
+		}
+
+		for chunkY := floorDiv(view.Min.Y, ChunkSize); chunkY*ChunkSize < view.Max.Y; chunkY += 1 {
+			for chunkX := floorDiv(view.Min.X, ChunkSize); chunkX*ChunkSize < view.Max.X; chunkX += 1 {
+				key := chunkKey{i, image.Pt(chunkX, chunkY)}
+				screen.DrawImage(r.chunk(key), translated(drawOptions,
+					float64(chunkX*ChunkSize*gMap.TileWidth), float64(chunkY*ChunkSize*gMap.TileHeight)))
+			}
+		}
+	}
+}
+
+// chunk returns the image of a chunk, rendering it first if it isn't up to date.
+func (r *MapRenderer) chunk(key chunkKey) *ebiten.Image {
+	gMap := r.gameMap
+	img, ok := r.chunks[key]
+	if ok && !r.dirty[key] {
+		return img
+	}
+	if !ok {
+		img = ebiten.NewImage(ChunkSize*gMap.TileWidth, ChunkSize*gMap.TileHeight)
+		r.chunks[key] = img
+	}
+	img.Clear()
+	delete(r.dirty, key)
+
+	// neighbouring tiles are drawn as well for the parts of them inside the chunk
+	tiles := image.Rect(key.chunk.X*ChunkSize, key.chunk.Y*ChunkSize,
+		(key.chunk.X+1)*ChunkSize, (key.chunk.Y+1)*ChunkSize)
+	view := image.Rect(tiles.Min.X-r.marginX, tiles.Min.Y-r.marginY, tiles.Max.X+r.marginX, tiles.Max.Y+r.marginY).
+		Intersect(image.Rect(0, 0, gMap.Width, gMap.Height))
+	drawOptions := ebiten.DrawImageOptions{}
+	drawOptions.GeoM.Translate(float64(-tiles.Min.X*gMap.TileWidth), float64(-tiles.Min.Y*gMap.TileHeight))
+	drawLayer(gMap, gMap.Layers[key.layer], r.tilesets, 0, view, img, drawOptions)
+	return img
+}
+
+// Invalidate is called after the tiles of the cell at x, y have changed, so
+// the chunks showing it get rendered again.
+func (r *MapRenderer) Invalidate(x, y int) {
+	for i, layer := range r.gameMap.Layers {
+		if r.isAnimated(layer.Tiles[y*r.gameMap.Width+x]) {
+			r.static[i] = false
+		}
+		for chunkY := floorDiv(y-r.marginY, ChunkSize); chunkY <= floorDiv(y+r.marginY, ChunkSize); chunkY += 1 {
+			for chunkX := floorDiv(x-r.marginX, ChunkSize); chunkX <= floorDiv(x+r.marginX, ChunkSize); chunkX += 1 {
+				key := chunkKey{i, image.Pt(chunkX, chunkY)}
+				if _, ok := r.chunks[key]; ok {
+					r.dirty[key] = true
+				}
+			}
+		}
+	}
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
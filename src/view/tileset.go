@@ -0,0 +1,129 @@
+package view
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/lafriks/go-tiled"
+)
+
+// Tileset holds the images of a tiled.Tileset. Tilesets are either a single
+// sheet cut into tiles, taking margin and spacing into account, or a
+// collection of separate images, one per tile.
+type Tileset struct {
+	Tileset    *tiled.Tileset
+	Sheet      *ebiten.Image            // nil for image collections
+	Images     map[uint32]*ebiten.Image // tiles of an image collection
+	animations map[uint32][]*tiled.AnimationFrame
+	tiles      map[uint32]*ebiten.Image // tiles already cut from the sheet
+}
+
+// getTilesets loads the images of every tileset used by gameMap. Image paths
+// are relative to the tileset, as in Tiled.
+func getTilesets(mapFS fs.FS, gameMap *tiled.Map) (map[*tiled.Tileset]*Tileset, error) {
+	tilesets := map[*tiled.Tileset]*Tileset{}
+	for _, ts := range gameMap.Tilesets {
+		tileset := &Tileset{
+			Tileset:    ts,
+			Images:     map[uint32]*ebiten.Image{},
+			animations: map[uint32][]*tiled.AnimationFrame{},
+			tiles:      map[uint32]*ebiten.Image{},
+		}
+
+		if ts.Image != nil {
+			sheet, err := loadTilesetImage(mapFS, ts, ts.Image.Source)
+			if err != nil {
+				return nil, err
+			}
+			tileset.Sheet = sheet
+		}
+		for _, tile := range ts.Tiles {
+			if tile.Image != nil {
+				tileImage, err := loadTilesetImage(mapFS, ts, tile.Image.Source)
+				if err != nil {
+					return nil, err
+				}
+				tileset.Images[tile.ID] = tileImage
+			}
+			if len(tile.Animation) > 0 {
+				tileset.animations[tile.ID] = tile.Animation
+			}
+		}
+		tilesets[ts] = tileset
+	}
+	return tilesets, nil
+}
+
+func loadTilesetImage(mapFS fs.FS, ts *tiled.Tileset, source string) (*ebiten.Image, error) {
+	imagePath := path.Clean(filepath.ToSlash(ts.GetFileFullPath(source)))
+	file, err := mapFS.Open(imagePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	tsImage, _, err := ebitenutil.NewImageFromReader(file)
+	if err != nil {
+		return nil, fmt.Errorf("tileset %s: %w", ts.Name, err)
+	}
+	return tsImage, nil
+}
+
+// TileImage returns the image to draw for tile id at ms milliseconds into the
+// game, following the tile's animation if it has one.
+func (t *Tileset) TileImage(id uint32, ms int) *ebiten.Image {
+	id = t.animatedTile(id, ms)
+	if tileImage, ok := t.Images[id]; ok {
+		return tileImage
+	}
+	if tileImage, ok := t.tiles[id]; ok {
+		return tileImage
+	}
+	if t.Sheet == nil {
+		return nil
+	}
+	tileImage := t.Sheet.SubImage(t.Tileset.GetTileRect(id)).(*ebiten.Image)
+	t.tiles[id] = tileImage
+	return tileImage
+}
+
+// animatedTile returns the frame of tile id's animation shown at ms.
+func (t *Tileset) animatedTile(id uint32, ms int) uint32 {
+	frames := t.animations[id]
+	total := 0
+	for _, frame := range frames {
+		total += int(frame.Duration)
+	}
+	if total == 0 {
+		return id
+	}
+
+	elapsed := ms % total
+	for _, frame := range frames {
+		if elapsed < int(frame.Duration) {
+			return frame.TileID
+		}
+		elapsed -= int(frame.Duration)
+	}
+	return id
+}
+
+// DrawTile draws tile id with its bottom left corner at the bottom left of the
+// cell at world position x, y, like Tiled does for tiles bigger than the map
+// grid.
+func (t *Tileset) DrawTile(screen *ebiten.Image, id uint32, ms int, x, y, cellHeight int, drawOptions ebiten.DrawImageOptions) {
+	tileImage := t.TileImage(id, ms)
+	if tileImage == nil {
+		return
+	}
+
+	offsetX, offsetY := 0, cellHeight-tileImage.Bounds().Dy()
+	if t.Tileset.TileOffset != nil {
+		offsetX += t.Tileset.TileOffset.X
+		offsetY += t.Tileset.TileOffset.Y
+	}
+	screen.DrawImage(tileImage, translated(drawOptions, float64(x+offsetX), float64(y+offsetY)))
+}
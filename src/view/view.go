@@ -0,0 +1,143 @@
+// Package view shows a game in a window with Ebiten. It draws the map, the
+// characters and the controls, reads the keyboard and mouse, and reads back
+// the screen for captures. The game doesn't depend on it, so everything but
+// the window runs without a display.
+package view
+
+import (
+	"a-star/src/game"
+	"a-star/src/utils"
+	"fmt"
+	"image"
+	"math"
+	"runtime/debug"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/lafriks/go-tiled"
+)
+
+// View is the window of a game. It implements ebiten.Game.
+type View struct {
+	Game        *game.Game
+	images      *AssetCache
+	tilesets    map[*tiled.Tileset]*Tileset
+	mapRenderer *MapRenderer
+	err         error // a panic while drawing, returned by the next update
+}
+
+// New opens a window for g, which reads its input from the window from now
+// on.
+func New(g *game.Game) (*View, error) {
+	v := &View{Game: g, images: NewAssetCache(g.Assets)}
+	if err := v.LoadLevel(g.Levels[g.Level], g.GameMap); err != nil {
+		return nil, err
+	}
+	g.View = v
+	g.Device = Device{}
+	g.Input = g.Device
+
+	ebiten.SetWindowSize(g.WindowSize())
+	ebiten.SetWindowResizingMode(ebiten.WindowResizingModeEnabled)
+	return v, nil
+}
+
+// Run shows the window until it is closed. A panic in the game is returned as
+// an error, since Ebiten runs the game on a goroutine of its own where a panic
+// would end the program before the caller could save anything, e.g. the
+// recording of the session.
+func (v *View) Run() error {
+	return ebiten.RunGame(v)
+}
+
+// LoadLevel loads the tilesets of gameMap and renders it from now on.
+func (v *View) LoadLevel(level game.Level, gameMap *tiled.Map) error {
+	tilesets, err := getTilesets(v.Game.MapFS, gameMap)
+	if err != nil {
+		return err
+	}
+	v.tilesets = tilesets
+	v.mapRenderer = NewMapRenderer(gameMap, tilesets)
+	ebiten.SetWindowTitle("A Star Algorithm Implementation - " + level.Name)
+	return nil
+}
+
+func (v *View) TilesChanged(x, y int) {
+	v.mapRenderer.Invalidate(x, y)
+}
+
+func (v *View) Update() (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = panicError(r)
+		}
+	}()
+	if v.err != nil {
+		return v.err
+	}
+	return v.Game.Update()
+}
+
+func (v *View) Draw(screen *ebiten.Image) {
+	defer func() {
+		if r := recover(); r != nil && v.err == nil {
+			v.err = panicError(r)
+		}
+	}()
+	g := v.Game
+	if g.Screen == utils.LevelSelectScreen {
+		drawLevelSelect(g, screen)
+		return
+	}
+
+	// the world is drawn through the camera, the buttons straight onto the screen
+	worldOptions := ebiten.DrawImageOptions{GeoM: cameraGeoM(g.Camera)}
+	view := g.Camera.VisibleTiles(g.GameMap.TileWidth, g.GameMap.TileHeight, g.GameMap.Width, g.GameMap.Height)
+	v.mapRenderer.Draw(screen, g.Clock.Millis(), view, worldOptions)
+	v.drawFences(screen, worldOptions)
+	drawPlayer(v.images, g.Player, screen, worldOptions)
+	drawChickens(v.images, g.Chickens, screen, worldOptions)
+	drawSearchView(g, screen)
+
+	drawEditor(g, screen)
+	g.Controls.Draw(&canvas{screen: screen, images: v.images})
+
+	if g.Capture != nil {
+		g.CaptureScreen(func() image.Image {
+			img := image.NewRGBA(screen.Bounds())
+			screen.ReadPixels(img.Pix)
+			return img
+		})
+	}
+}
+
+func (v *View) Layout(outsideWidth, outsideHeight int) (screenWidth, screenHeight int) {
+	return v.Game.Layout(outsideWidth, outsideHeight)
+}
+
+// panicError turns a recovered panic into an error with the stack it came
+// from.
+func panicError(r any) error {
+	return fmt.Errorf("panic: %v\n%s", r, debug.Stack())
+}
+
+// cameraGeoM returns the transform from world to screen coordinates.
+func cameraGeoM(camera *game.Camera) ebiten.GeoM {
+	geoM := ebiten.GeoM{}
+	geoM.Scale(camera.Zoom, camera.Zoom)
+	geoM.Translate(camera.Offset())
+	return geoM
+}
+
+// translated returns drawOptions with an image moved to world position x, y
+// before the camera transform in drawOptions.GeoM is applied.
+func translated(drawOptions ebiten.DrawImageOptions, x, y float64) *ebiten.DrawImageOptions {
+	geoM := ebiten.GeoM{}
+	geoM.Translate(x, y)
+	geoM.Concat(drawOptions.GeoM)
+	drawOptions.GeoM = geoM
+	return &drawOptions
+}
+
+func floorDiv(a, b int) int {
+	return int(math.Floor(float64(a) / float64(b)))
+}
@@ -0,0 +1,12 @@
+//go:build !headless
+
+package main
+
+import (
+	"a-star/src/game"
+	"a-star/src/view"
+)
+
+func openWindow(g *game.Game) (window, error) {
+	return view.New(g)
+}
@@ -0,0 +1,16 @@
+//go:build headless
+
+package main
+
+import (
+	"a-star/src/game"
+	"fmt"
+)
+
+// openWindow fails when built with the headless tag. The binary then doesn't
+// link Ebiten, which needs a display as soon as it is loaded, so it can run the
+// modes without a window on machines without one: -headless, -replay with
+// -verify, -export, -capture and -compare.
+func openWindow(g *game.Game) (window, error) {
+	return nil, fmt.Errorf("built without a window, run with -headless or another mode that needs none")
+}